@@ -2,6 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +24,24 @@ type Account struct {
 	*binance.Client
 	Name     string            `json:"name"`
 	Balances []binance.Balance `json:"balances"`
+	Assets   []string          `json:"-"`
+	Symbols  []string          `json:"-"`
+}
+
+// assetsOr return default assets of account, or assets if not configured
+func (account *Account) assetsOr(assets []string) []string {
+	if len(account.Assets) > 0 {
+		return account.Assets
+	}
+	return assets
+}
+
+// symbolsOr return symbols if not empty, or default symbols of account
+func (account *Account) symbolsOr(symbols []string) []string {
+	if len(symbols) > 0 {
+		return symbols
+	}
+	return account.Symbols
 }
 
 // UpdateBalances update account balances
@@ -64,6 +87,9 @@ func (account *Account) ListOpenOrders(symbol string) ([]*binance.Order, error)
 
 // ListPrices list latest prices for a symbol or symbols
 func (account *Account) ListPrices(symbol string) ([]*binance.SymbolPrice, error) {
+	if prices, ok := cachedPrices(symbol); ok {
+		return prices, nil
+	}
 	ctx, cancel := newContext()
 	defer cancel()
 	service := account.NewListPricesService()
@@ -74,6 +100,10 @@ func (account *Account) ListPrices(symbol string) ([]*binance.SymbolPrice, error
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	cachePrices(symbol, prices)
+	if symbol == "" {
+		saveSymbols(prices)
+	}
 	return prices, nil
 }
 
@@ -88,17 +118,296 @@ func (account *Account) CancelOrder(symbol string, orderID int64) error {
 	return nil
 }
 
-// CreateOrder create order
-func (account *Account) CreateOrder(symbol, side, quantity, price string) (*binance.CreateOrderResponse, error) {
+// CancelOpenOrders cancel open orders of symbol, all symbols if empty
+func (account *Account) CancelOpenOrders(symbol string) ([]int64, error) {
+	var canceledOrders []int64
+	orders, err := account.ListOpenOrders(symbol)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, order := range orders {
+		err = account.CancelOrder(order.Symbol, order.OrderID)
+		if err != nil {
+			return canceledOrders, errors.Trace(err)
+		}
+		canceledOrders = append(canceledOrders, order.OrderID)
+	}
+	return canceledOrders, nil
+}
+
+// CreateOrder create order, retry up to retries times on transient errors.
+// The same client order id is used by all attempts, and before each retry
+// the order is queried in case the failed attempt actually landed.
+func (account *Account) CreateOrder(symbol, side, quantity, price string, retries int) (*binance.CreateOrderResponse, error) {
+	side = strings.ToUpper(side)
+	err := checkTrade(&TradeCheck{
+		Account:  account.Name,
+		Market:   "spot",
+		Symbol:   symbol,
+		Side:     side,
+		Type:     string(binance.OrderTypeLimit),
+		Quantity: quantity,
+		Price:    price,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	clientOrderID := newClientOrderID()
+	for attempt := 0; ; attempt++ {
+		res, err := account.createOrder(symbol, binance.SideType(side), quantity, price, clientOrderID)
+		if err == nil {
+			return res, nil
+		}
+		if attempt >= retries || !isRetryable(err) {
+			return nil, errors.Trace(err)
+		}
+		time.Sleep(time.Duration(attempt+1) * time.Second)
+		order, qerr := account.getOrderByClientID(symbol, clientOrderID)
+		if qerr == nil {
+			log.Printf("order %s of %s landed despite error: %s", clientOrderID, account.Name, err)
+			return &binance.CreateOrderResponse{
+				Symbol:                   order.Symbol,
+				OrderID:                  order.OrderID,
+				ClientOrderID:            order.ClientOrderID,
+				TransactTime:             order.Time,
+				Price:                    order.Price,
+				OrigQuantity:             order.OrigQuantity,
+				ExecutedQuantity:         order.ExecutedQuantity,
+				CummulativeQuoteQuantity: order.CummulativeQuoteQuantity,
+				Status:                   order.Status,
+				TimeInForce:              order.TimeInForce,
+				Type:                     order.Type,
+				Side:                     order.Side,
+			}, nil
+		}
+		if !isOrderNotFound(qerr) {
+			return nil, errors.Annotatef(err, "failed to verify order %s: %s", clientOrderID, qerr)
+		}
+		log.Printf("retrying order %s of %s after error: %s", clientOrderID, account.Name, err)
+	}
+}
+
+func (account *Account) createOrder(symbol string, side binance.SideType, quantity, price,
+	clientOrderID string) (*binance.CreateOrderResponse, error) {
 	ctx, cancel := newContext()
 	defer cancel()
-	side = strings.ToUpper(side)
-	sideType := binance.SideType(side)
-	res, err := account.NewCreateOrderService().Symbol(symbol).Side(sideType).
+	res, err := account.NewCreateOrderService().Symbol(symbol).Side(side).
 		Quantity(quantity).Price(price).Type(binance.OrderTypeLimit).
-		TimeInForce(binance.TimeInForceTypeGTC).Do(ctx)
+		TimeInForce(binance.TimeInForceTypeGTC).NewClientOrderID(clientOrderID).Do(ctx)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	return res, nil
 }
+
+func (account *Account) getOrderByClientID(symbol, clientOrderID string) (*binance.Order, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	order, err := account.NewGetOrderService().Symbol(symbol).OrigClientOrderID(clientOrderID).Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return order, nil
+}
+
+// ListTrades list account trades of symbol between startTime and endTime
+func (account *Account) ListTrades(symbol string, startTime, endTime int64) ([]*binance.TradeV3, error) {
+	const (
+		window = int64(24 * time.Hour / time.Millisecond)
+		limit  = 1000
+	)
+	var trades []*binance.TradeV3
+	progress := startProgress("trades of "+symbol, 0)
+	defer progress.Done()
+	for start := startTime; start < endTime; start += window {
+		end := start + window - 1
+		if end > endTime {
+			end = endTime
+		}
+		ctx, cancel := newContext()
+		res, err := account.NewListTradesService().Symbol(symbol).
+			StartTime(start).EndTime(end).Limit(limit).Do(ctx)
+		cancel()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for len(res) > 0 {
+			for _, trade := range res {
+				if trade.Time <= end {
+					trades = append(trades, trade)
+				}
+			}
+			progress.Add(len(res))
+			last := res[len(res)-1]
+			if len(res) < limit || last.Time > end {
+				break
+			}
+			ctx, cancel = newContext()
+			res, err = account.NewListTradesService().Symbol(symbol).
+				FromID(last.ID + 1).Limit(limit).Do(ctx)
+			cancel()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	return trades, nil
+}
+
+// GetSymbols get symbol info of symbols, all symbols if empty
+func (account *Account) GetSymbols(symbols []string) (map[string]binance.Symbol, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	info, err := account.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	res := make(map[string]binance.Symbol)
+	for _, symbol := range info.Symbols {
+		if len(symbols) == 0 || StrContains(symbols, symbol.Symbol) {
+			res[symbol.Symbol] = symbol
+		}
+	}
+	return res, nil
+}
+
+// RollingWindowStats define price change stats over a rolling window
+type RollingWindowStats struct {
+	Symbol             string `json:"symbol"`
+	PriceChange        string `json:"priceChange"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	WeightedAvgPrice   string `json:"weightedAvgPrice"`
+	OpenPrice          string `json:"openPrice"`
+	HighPrice          string `json:"highPrice"`
+	LowPrice           string `json:"lowPrice"`
+	LastPrice          string `json:"lastPrice"`
+	Volume             string `json:"volume"`
+	QuoteVolume        string `json:"quoteVolume"`
+	OpenTime           int64  `json:"openTime"`
+	CloseTime          int64  `json:"closeTime"`
+	Count              int64  `json:"count"`
+}
+
+// ListRollingWindowStats list price change stats of symbols over window: 1m-59m, 1h-23h, 1d-7d
+func (account *Account) ListRollingWindowStats(symbols []string, window string) ([]*RollingWindowStats, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if window != "" {
+		params.Set("windowSize", window)
+	}
+	if len(symbols) == 1 {
+		params.Set("symbol", symbols[0])
+		stats := new(RollingWindowStats)
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/api/v3/ticker", params, false, stats)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []*RollingWindowStats{stats}, nil
+	}
+	data, err := json.Marshal(symbols)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	params.Set("symbols", string(data))
+	var stats []*RollingWindowStats
+	err = account.callAPI(ctx, http.MethodGet, apiURL, "/api/v3/ticker", params, false, &stats)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return stats, nil
+}
+
+// GetOrder get order of symbol by order id
+func (account *Account) GetOrder(symbol string, orderID int64) (*binance.Order, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	order, err := account.NewGetOrderService().Symbol(symbol).OrderID(orderID).Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return order, nil
+}
+
+// ListOrderTrades list trades filling order of symbol
+func (account *Account) ListOrderTrades(symbol string, orderID int64) ([]*binance.TradeV3, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":  {symbol},
+		"orderId": {strconv.FormatInt(orderID, 10)},
+	}
+	var trades []*binance.TradeV3
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/api/v3/myTrades", params, true, &trades)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return trades, nil
+}
+
+// GetBookTicker get best bid and ask of symbol
+func (account *Account) GetBookTicker(symbol string) (*binance.BookTicker, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	tickers, err := account.NewListBookTickersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(tickers) == 0 {
+		return nil, errors.NotFoundf("book ticker of %s", symbol)
+	}
+	return tickers[0], nil
+}
+
+// ListKlines list klines of symbol by interval
+func (account *Account) ListKlines(symbol, interval string, limit int, startTime, endTime int64) ([]*binance.Kline, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	service := account.NewKlinesService().Symbol(symbol).Interval(interval)
+	if limit > 0 {
+		service = service.Limit(limit)
+	}
+	if startTime > 0 {
+		service = service.StartTime(startTime)
+	}
+	if endTime > 0 {
+		service = service.EndTime(endTime)
+	}
+	klines, err := service.Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return klines, nil
+}
+
+// ListPriceChangeStats list 24hr price change stats of all symbols
+func (account *Account) ListPriceChangeStats() ([]*binance.PriceChangeStats, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	stats, err := account.NewListPriceChangeStatsService().Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return stats, nil
+}
+
+// ListAllTrades list full trade history of symbol
+func (account *Account) ListAllTrades(symbol string) ([]*binance.TradeV3, error) {
+	const limit = 1000
+	var trades []*binance.TradeV3
+	var fromID int64
+	for {
+		ctx, cancel := newContext()
+		res, err := account.NewListTradesService().Symbol(symbol).
+			FromID(fromID).Limit(limit).Do(ctx)
+		cancel()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		trades = append(trades, res...)
+		if len(res) < limit {
+			return trades, nil
+		}
+		fromID = res[len(res)-1].ID + 1
+	}
+}
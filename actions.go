@@ -1,36 +1,132 @@
 package main
 
 import (
+	"fmt"
+	"log"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adshao/go-binance"
 	"github.com/juju/errors"
 )
 
-func listBalances(assets []string, total bool) error {
+// BalanceRow define balance of an asset, total of free and locked amounts,
+// with optional values of the total in BTC and quote asset
+type BalanceRow struct {
+	binance.Balance
+	Total    string   `json:"total"`
+	ValueBTC *float64 `json:"value_btc,omitempty"`
+	Value    *float64 `json:"value,omitempty"`
+	Quote    string   `json:"quote,omitempty"`
+}
+
+// balanceRows convert balances into rows valued in BTC and quote with graph,
+// rows are not valued if graph is nil
+func balanceRows(balances []binance.Balance, graph *PriceGraph, quote string) []*BalanceRow {
+	rows := make([]*BalanceRow, len(balances))
+	for i, balance := range balances {
+		amount := StrToFloat(balance.Free) + StrToFloat(balance.Locked)
+		rows[i] = &BalanceRow{Balance: balance, Total: strconv.FormatFloat(amount, 'f', 8, 64)}
+		if graph == nil {
+			continue
+		}
+		if valueBTC, err := graph.Convert(amount, balance.Asset, "BTC"); err == nil {
+			valueBTC = roundTotal(valueBTC, "BTC")
+			rows[i].ValueBTC = &valueBTC
+		}
+		if value, err := graph.Convert(amount, balance.Asset, quote); err == nil {
+			value = roundTotal(value, quote)
+			rows[i].Value = &value
+			rows[i].Quote = strings.ToUpper(quote)
+		}
+	}
+	return rows
+}
+
+// BalanceTotalValue define values in quote asset of balances aggregated
+// across accounts by asset, and their grand total
+type BalanceTotalValue struct {
+	Quote    string             `json:"quote"`
+	Values   map[string]float64 `json:"values"`
+	Total    float64            `json:"total"`
+	Unpriced []string           `json:"unpriced,omitempty"`
+}
+
+// nonZeroBalances filter out balances with neither free nor locked amount
+func nonZeroBalances(balances []binance.Balance) []binance.Balance {
+	var res []binance.Balance
+	for _, balance := range balances {
+		if StrToFloat(balance.Free) != 0 || StrToFloat(balance.Locked) != 0 {
+			res = append(res, balance)
+		}
+	}
+	return res
+}
+
+// listBalances list balances of assets, or all non-zero balances if all is
+// set. With total, balances are also aggregated across accounts by asset and
+// valued in quote asset if set.
+func listBalances(assets []string, assetsSet, all, total bool, quote, wallet string) error {
+	var graph *PriceGraph
 	return accountsDo(func(account *Account) (interface{}, error) {
-		err := account.UpdateBalances(assets)
+		accountAssets := assets
+		if !assetsSet {
+			accountAssets = account.assetsOr(assets)
+		}
+		if all {
+			accountAssets = nil
+		}
+		balances, err := account.WalletBalances(wallet, accountAssets)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		return account.Balances, nil
+		if all {
+			balances = nonZeroBalances(balances)
+		}
+		if quote != "" && graph == nil {
+			graph, err = account.NewPriceGraph()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return balanceRows(balances, graph, quote), nil
 	}, func(results map[string]interface{}) (interface{}, error) {
 		if !total {
 			return results, nil
 		}
 		totalResults := make(map[string]float64)
 		for _, res := range results {
-			assets, ok := res.([]binance.Balance)
+			rows, ok := res.([]*BalanceRow)
 			if !ok {
 				continue
 			}
-			for _, asset := range assets {
-				free, _ := strconv.ParseFloat(asset.Free, 64)
-				locked, _ := strconv.ParseFloat(asset.Locked, 64)
-				totalResults[asset.Asset] += free + locked
+			for _, row := range rows {
+				free, _ := strconv.ParseFloat(row.Free, 64)
+				locked, _ := strconv.ParseFloat(row.Locked, 64)
+				totalResults[row.Asset] += free + locked
+			}
+		}
+		for asset, total := range totalResults {
+			totalResults[asset] = roundTotal(total, asset)
+		}
+		if graph == nil {
+			return []interface{}{results, totalResults}, nil
+		}
+		totalValue := &BalanceTotalValue{Quote: strings.ToUpper(quote), Values: make(map[string]float64)}
+		for asset, total := range totalResults {
+			value, err := graph.Convert(total, asset, quote)
+			if err != nil {
+				totalValue.Unpriced = append(totalValue.Unpriced, asset)
+				continue
 			}
+			totalValue.Values[asset] = roundTotal(value, quote)
+			totalValue.Total += value
 		}
-		return []interface{}{results, totalResults}, nil
+		sort.Strings(totalValue.Unpriced)
+		totalValue.Total = roundTotal(totalValue.Total, quote)
+		return []interface{}{results, totalResults, totalValue}, nil
 	})
 }
 
@@ -44,43 +140,550 @@ func listOpenOrders(symbol string) error {
 	})
 }
 
-func listPrices(symbol string) error {
+// depositHistorySpan is the max time range of a request of deposit history
+const depositHistorySpan = 90 * 24 * time.Hour
+
+func listDeposits(asset string, status int, page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var deposits []*DepositRecord
+		err := page.Fetch(depositHistorySpan, func(startTime, endTime int64) error {
+			res, err := account.ListDeposits(asset, status, startTime, endTime)
+			deposits = append(deposits, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return page.Apply(deposits), nil
+	})
+}
+
+func getDepositAddress(asset, network string) error {
+	if asset == "" {
+		return errors.New("asset required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		if network == "" {
+			coins, err := account.GetCoinInfo(asset)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			var networks []string
+			for _, n := range coins[0].NetworkList {
+				if n.DepositEnable {
+					networks = append(networks, n.Network)
+				}
+			}
+			if len(networks) > 1 {
+				log.Printf("WARNING: %s can be deposited on networks %s, showing address of default network, "+
+					"make sure the sender uses the same network or set --network", asset, strings.Join(networks, ", "))
+			}
+		}
+		address, err := account.GetDepositAddress(asset, network)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return address, nil
+	})
+}
+
+// AssetDetail define deposit and withdraw settings of an asset with its
+// networks sorted by withdraw fee, withdrawable networks first
+type AssetDetail struct {
+	Asset           string         `json:"asset"`
+	Name            string         `json:"name"`
+	DepositEnabled  bool           `json:"depositEnabled"`
+	WithdrawEnabled bool           `json:"withdrawEnabled"`
+	CheapestNetwork string         `json:"cheapestNetwork,omitempty"`
+	Networks        []*CoinNetwork `json:"networks"`
+}
+
+func listAssetDetails(assets []string) error {
+	if len(assets) == 0 {
+		return errors.New("assets required")
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		coins, err := account.GetCoinInfo("")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var details []*AssetDetail
+		for _, asset := range assets {
+			var coin *CoinInfo
+			for _, c := range coins {
+				if strings.EqualFold(c.Coin, asset) {
+					coin = c
+					break
+				}
+			}
+			if coin == nil {
+				return nil, errors.NotFoundf("asset %s", asset)
+			}
+			networks := append([]*CoinNetwork(nil), coin.NetworkList...)
+			sort.SliceStable(networks, func(i, j int) bool {
+				if networks[i].WithdrawEnable != networks[j].WithdrawEnable {
+					return networks[i].WithdrawEnable
+				}
+				return StrToFloat(networks[i].WithdrawFee) < StrToFloat(networks[j].WithdrawFee)
+			})
+			detail := &AssetDetail{
+				Asset:           coin.Coin,
+				Name:            coin.Name,
+				DepositEnabled:  coin.DepositAllEnable,
+				WithdrawEnabled: coin.WithdrawAllEnable,
+				Networks:        networks,
+			}
+			if len(networks) > 0 && networks[0].WithdrawEnable {
+				detail.CheapestNetwork = networks[0].Network
+			}
+			details = append(details, detail)
+		}
+		return details, nil
+	})
+}
+
+// convertDust convert small balances of assets to BNB, only preview
+// convertible balances with dryRun or when assets are not selected
+func convertDust(assets []string, dryRun bool) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		dust, err := account.ListDustAssets()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(assets) == 0 {
+			return dust, nil
+		}
+		preview := &DustAssets{DribbletPercentage: dust.DribbletPercentage}
+		var totalBTC, totalBNB float64
+		var selected []string
+		for _, detail := range dust.Details {
+			if StrContains(assets, detail.Asset) {
+				preview.Details = append(preview.Details, detail)
+				totalBTC += StrToFloat(detail.ToBTC)
+				totalBNB += StrToFloat(detail.ToBNB)
+				selected = append(selected, detail.Asset)
+			}
+		}
+		preview.TotalTransferBtc = fmt.Sprintf("%.8f", totalBTC)
+		preview.TotalTransferBNB = fmt.Sprintf("%.8f", totalBNB)
+		if dryRun {
+			return preview, nil
+		}
+		if len(selected) == 0 {
+			return nil, errors.New("no convertible balance of selected assets")
+		}
+		res, err := account.ConvertDust(selected)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return res, nil
+	})
+}
+
+// DustLogSummary define conversions of small balances with totals
+type DustLogSummary struct {
+	Logs               []*DustLog         `json:"logs"`
+	TotalBNB           float64            `json:"total_bnb"`
+	TotalServiceCharge float64            `json:"total_service_charge"`
+	FromAssets         map[string]float64 `json:"from_assets"`
+}
+
+// dustLogSpan is the max time range of a request of dust logs
+const dustLogSpan = 90 * 24 * time.Hour
+
+func listDustLogs(page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var logs []*DustLog
+		err := page.Fetch(dustLogSpan, func(startTime, endTime int64) error {
+			res, err := account.ListDustLogs(startTime, endTime)
+			logs = append(logs, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		logs = page.Apply(logs).([]*DustLog)
+		summary := &DustLogSummary{Logs: logs, FromAssets: make(map[string]float64)}
+		for _, l := range logs {
+			summary.TotalBNB += StrToFloat(l.TotalTransferedAmount)
+			summary.TotalServiceCharge += StrToFloat(l.TotalServiceChargeAmount)
+			for _, detail := range l.Details {
+				summary.FromAssets[detail.FromAsset] += StrToFloat(detail.Amount)
+			}
+		}
+		summary.TotalBNB = roundTotal(summary.TotalBNB, "BNB")
+		summary.TotalServiceCharge = roundTotal(summary.TotalServiceCharge, "BNB")
+		for asset, amount := range summary.FromAssets {
+			summary.FromAssets[asset] = roundTotal(amount, asset)
+		}
+		return summary, nil
+	})
+}
+
+// accountSnapshotSpan is the max time range of a request of account snapshots,
+// one snapshot a day for at most 30 days
+const accountSnapshotSpan = 30 * 24 * time.Hour
+
+// listAccountSnapshots list daily snapshots of a wallet, the last 7 days by
+// default of the API. The limit is the number of days of each request.
+func listAccountSnapshots(walletType string, page *Page) error {
+	limit := page.Limit
+	if page.All || limit > 30 {
+		limit = 30
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var snapshots []*AccountSnapshot
+		err := page.Fetch(accountSnapshotSpan, func(startTime, endTime int64) error {
+			res, err := account.ListAccountSnapshots(walletType, limit, startTime, endTime)
+			snapshots = append(snapshots, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return page.Apply(snapshots), nil
+	})
+}
+
+// TradeFeeRow define commission rates of a symbol with rates after BNB discount
+type TradeFeeRow struct {
+	TradeFee
+	Discount      string `json:"discount,omitempty"`
+	DiscountMaker string `json:"discountMaker,omitempty"`
+	DiscountTaker string `json:"discountTaker,omitempty"`
+}
+
+// listTradeFees list commission rates of symbols for each account, or of all
+// symbols if not set. Rates after BNB discount are only shown for selected symbols.
+func listTradeFees(symbols []string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var fees []*TradeFee
+		if len(symbols) == 0 {
+			res, err := account.ListTradeFees("")
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			fees = res
+		}
+		for _, symbol := range symbols {
+			res, err := account.ListTradeFees(symbol)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			fees = append(fees, res...)
+		}
+		rows := make([]*TradeFeeRow, len(fees))
+		for i, fee := range fees {
+			rows[i] = &TradeFeeRow{TradeFee: *fee}
+			if len(symbols) == 0 {
+				continue
+			}
+			discount, err := account.GetCommissionDiscount(fee.Symbol)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !discount.EnabledForAccount || !discount.EnabledForSymbol {
+				continue
+			}
+			// discount is the ratio of standard commission paid in BNB, e.g. 0.75
+			ratio := StrToFloat(discount.Discount)
+			rows[i].Discount = discount.Discount
+			rows[i].DiscountMaker = strconv.FormatFloat(StrToFloat(fee.MakerCommission)*ratio, 'f', -1, 64)
+			rows[i].DiscountTaker = strconv.FormatFloat(StrToFloat(fee.TakerCommission)*ratio, 'f', -1, 64)
+		}
+		return rows, nil
+	})
+}
+
+// KeyPermissions define permissions of an API key with warnings of risky ones
+type KeyPermissions struct {
+	*APIRestrictions
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// KeyAudit define permissions of API keys of accounts and the over-privileged ones
+type KeyAudit struct {
+	Accounts       map[string]interface{} `json:"accounts"`
+	OverPrivileged []string               `json:"over_privileged"`
+}
+
+func auditKeyPermissions() error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		restrictions, err := account.GetAPIRestrictions()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		perms := &KeyPermissions{APIRestrictions: restrictions}
+		if restrictions.EnableWithdrawals {
+			perms.Warnings = append(perms.Warnings, "withdrawals enabled")
+		}
+		if restrictions.PermitsUniversalTransfer {
+			perms.Warnings = append(perms.Warnings, "universal transfer enabled")
+		}
+		if restrictions.EnableInternalTransfer {
+			perms.Warnings = append(perms.Warnings, "internal transfer enabled")
+		}
+		if len(perms.Warnings) > 0 && !restrictions.IPRestrict {
+			perms.Warnings = append(perms.Warnings, "no IP restriction")
+		}
+		return perms, nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		audit := &KeyAudit{Accounts: results, OverPrivileged: []string{}}
+		for name, res := range results {
+			if perms, ok := res.(*KeyPermissions); ok && len(perms.Warnings) > 0 {
+				audit.OverPrivileged = append(audit.OverPrivileged, name)
+			}
+		}
+		sort.Strings(audit.OverPrivileged)
+		return audit, nil
+	})
+}
+
+// StatusReport define status of accounts and the restricted ones
+type StatusReport struct {
+	Accounts   map[string]interface{} `json:"accounts"`
+	Restricted []string               `json:"restricted"`
+}
+
+func listAccountStatus() error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		status, err := account.GetAccountStatus()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return status, nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		report := &StatusReport{Accounts: results, Restricted: []string{}}
+		for name, res := range results {
+			// accounts failed to be checked are treated as restricted
+			if status, ok := res.(*AccountStatus); !ok || status.Restricted {
+				report.Restricted = append(report.Restricted, name)
+			}
+		}
+		sort.Strings(report.Restricted)
+		return report, nil
+	})
+}
+
+// parseSwitch parse on/off setting, nil if empty
+func parseSwitch(s string) (*bool, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return nil, nil
+	case "on", "true", "yes":
+		v := true
+		return &v, nil
+	case "off", "false", "no":
+		v := false
+		return &v, nil
+	}
+	return nil, errors.Errorf("invalid switch: %s, use on or off", s)
+}
+
+// bnbBurn show BNB burn settings of accounts, or toggle them if set
+func bnbBurn(spotSetting, interestSetting string) error {
+	spot, err := parseSwitch(spotSetting)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	interest, err := parseSwitch(interestSetting)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		if spot == nil && interest == nil {
+			burn, err := account.GetBNBBurn()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			return burn, nil
+		}
+		burn, err := account.SetBNBBurn(spot, interest)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return burn, nil
+	})
+}
+
+func listPrices(symbols []string, quote, sortBy string) error {
 	return runOnce(func(account *Account) (interface{}, error) {
+		symbol := ""
+		if len(symbols) == 1 {
+			symbol = symbols[0]
+		}
 		prices, err := account.ListPrices(symbol)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if quote != "" {
+			info, err := account.GetSymbols(nil)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			var filtered []*binance.SymbolPrice
+			for _, price := range prices {
+				if strings.EqualFold(info[price.Symbol].QuoteAsset, quote) {
+					filtered = append(filtered, price)
+				}
+			}
+			prices = filtered
+		}
+		if len(symbols) > 1 {
+			var filtered []*binance.SymbolPrice
+			for _, price := range prices {
+				if StrContains(symbols, price.Symbol) {
+					filtered = append(filtered, price)
+				}
+			}
+			prices = filtered
+		}
+		switch sortBy {
+		case "":
+		case "price":
+			sort.SliceStable(prices, func(i, j int) bool {
+				return StrToFloat(prices[i].Price) > StrToFloat(prices[j].Price)
+			})
+		case "volume":
+			stats, err := account.ListPriceChangeStats()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			volumes := make(map[string]float64)
+			for _, s := range stats {
+				volumes[s.Symbol] = StrToFloat(s.QuoteVolume)
+			}
+			sort.SliceStable(prices, func(i, j int) bool {
+				return volumes[prices[i].Symbol] > volumes[prices[j].Symbol]
+			})
+		default:
+			return nil, errors.Errorf("invalid sort field: %s", sortBy)
+		}
 		return prices, nil
 	})
 }
 
+// Movers define top gainers and losers by 24hr price change
+type Movers struct {
+	Gainers []*binance.PriceChangeStats `json:"gainers,omitempty"`
+	Losers  []*binance.PriceChangeStats `json:"losers,omitempty"`
+	Volume  []*binance.PriceChangeStats `json:"volume,omitempty"`
+}
+
+func listMovers(quote, by string, top int) error {
+	return runOnce(func(account *Account) (interface{}, error) {
+		stats, err := account.ListPriceChangeStats()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if quote != "" {
+			info, err := account.GetSymbols(nil)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			var filtered []*binance.PriceChangeStats
+			for _, s := range stats {
+				if strings.EqualFold(info[s.Symbol].QuoteAsset, quote) {
+					filtered = append(filtered, s)
+				}
+			}
+			stats = filtered
+		}
+		n := top
+		if n <= 0 || n > len(stats) {
+			n = len(stats)
+		}
+		switch by {
+		case "change", "":
+			sort.SliceStable(stats, func(i, j int) bool {
+				return StrToFloat(stats[i].PriceChangePercent) > StrToFloat(stats[j].PriceChangePercent)
+			})
+			movers := &Movers{Gainers: stats[:n]}
+			for i := len(stats) - 1; i >= len(stats)-n; i-- {
+				movers.Losers = append(movers.Losers, stats[i])
+			}
+			return movers, nil
+		case "volume":
+			sort.SliceStable(stats, func(i, j int) bool {
+				return StrToFloat(stats[i].QuoteVolume) > StrToFloat(stats[j].QuoteVolume)
+			})
+			return &Movers{Volume: stats[:n]}, nil
+		}
+		return nil, errors.Errorf("invalid rank field: %s", by)
+	})
+}
+
+func listRollingWindowStats(symbols []string, window string) error {
+	if len(symbols) == 0 {
+		return errors.New("symbol required")
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		stats, err := account.ListRollingWindowStats(symbols, window)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return stats, nil
+	})
+}
+
+func listKlines(symbol, interval string, limit int, startTime, endTime int64, indicatorItems []string) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	indicators, err := ParseIndicators(indicatorItems)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		klines, err := account.ListKlines(symbol, interval, limit, startTime, endTime)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return klineRows(klines, indicators), nil
+	})
+}
+
 func cancelOrders(symbol string) error {
 	return accountsDo(
 		func(account *Account) (interface{}, error) {
-			var canceledOrders []int64
-			orders, err := account.ListOpenOrders(symbol)
+			canceledOrders, err := account.CancelOpenOrders(symbol)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			for _, order := range orders {
-				err = account.CancelOrder(symbol, order.OrderID)
-				if err != nil {
-					return nil, errors.Trace(err)
-				}
-				canceledOrders = append(canceledOrders, order.OrderID)
-			}
 			return canceledOrders, nil
 		})
 }
 
-func createOrder(symbol, side, quantity, price string) error {
+// createOrder create order on each account, price is resolved from the live
+// book of each account when pegged
+func createOrder(symbol, side, quantity, price, peg, offset, strategy string, retries int) error {
+	if peg != "" && price != "" {
+		return errors.New("price and peg can not be set together")
+	}
 	return accountsDo(
 		func(account *Account) (interface{}, error) {
 			var orderIDs []int64
-			res, err := account.CreateOrder(symbol, side, quantity, price)
+			mid := account.arrivalMid(symbol)
+			price := price
+			if peg != "" {
+				var err error
+				price, err = account.pegPrice(symbol, side, peg, offset)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+			res, err := account.CreateOrder(symbol, side, quantity, price, retries)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
+			recordExecution(&Execution{
+				Account:    account.Name,
+				Strategy:   strategy,
+				Symbol:     symbol,
+				Side:       side,
+				OrderID:    res.OrderID,
+				ArrivalMid: mid,
+			})
 			orderIDs = append(orderIDs, res.OrderID)
 			return orderIDs, nil
 		})
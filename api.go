@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// base URLs of endpoints not covered by go-binance
+const (
+	apiURL     = "https://api.binance.com"
+	futuresURL = "https://fapi.binance.com"
+)
+
+// callAPI send request to endpoints not covered by go-binance and decode
+// JSON response into v. Signed requests are timestamped and signed with
+// the account secret key, errors are returned as *binance.APIError.
+func (account *Account) callAPI(ctx context.Context, method, baseURL, endpoint string,
+	params url.Values, signed bool, v interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if signed {
+		params.Set("timestamp", fmt.Sprintf("%d", MilliTime(time.Now())))
+		mac := hmac.New(sha256.New, []byte(account.SecretKey))
+		_, err := mac.Write([]byte(params.Encode()))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		params.Set("signature", fmt.Sprintf("%x", mac.Sum(nil)))
+	}
+	fullURL := baseURL + endpoint
+	var body string
+	if method == http.MethodGet || method == http.MethodDelete {
+		fullURL = fmt.Sprintf("%s?%s", fullURL, params.Encode())
+	} else {
+		body = params.Encode()
+	}
+	req, err := http.NewRequest(method, fullURL, strings.NewReader(body))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req = req.WithContext(ctx)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if account.APIKey != "" {
+		req.Header.Set("X-MBX-APIKEY", account.APIKey)
+	}
+	if account.Debug {
+		account.Logger.Printf("full url: %s, body: %s", fullURL, body)
+	}
+	res, err := account.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if account.Debug {
+		account.Logger.Printf("response status code: %d, body: %s", res.StatusCode, data)
+	}
+	if res.StatusCode >= 400 {
+		apiErr := new(binance.APIError)
+		e := json.Unmarshal(data, apiErr)
+		if e != nil {
+			apiErr.Code = int64(res.StatusCode)
+			apiErr.Message = string(data)
+		}
+		return apiErr
+	}
+	if v == nil {
+		return nil
+	}
+	return errors.Trace(json.Unmarshal(data, v))
+}
+
+// setTimeRange set limit and time range params of history requests, zero values are omitted
+func setTimeRange(params url.Values, limit int, startTime, endTime int64) {
+	if limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", limit))
+	}
+	if startTime > 0 {
+		params.Set("startTime", fmt.Sprintf("%d", startTime))
+	}
+	if endTime > 0 {
+		params.Set("endTime", fmt.Sprintf("%d", endTime))
+	}
+}
+
+// clientOrderIDPrefix is the prefix of client order ids of orders created by the CLI
+const clientOrderIDPrefix = "bcli"
+
+// newClientOrderID generate a unique client order id
+func newClientOrderID() string {
+	return fmt.Sprintf(clientOrderIDPrefix+"%d%04d", time.Now().UnixNano()/int64(time.Microsecond), rand.Intn(10000))
+}
+
+// isRetryable check if err is a transient error that may succeed on retry
+func isRetryable(err error) bool {
+	cause := errors.Cause(err)
+	if apiErr, ok := cause.(*binance.APIError); ok {
+		switch apiErr.Code {
+		case -1001, -1007, 0, http.StatusServiceUnavailable:
+			return true
+		}
+		return false
+	}
+	if cause == context.DeadlineExceeded {
+		return true
+	}
+	if netErr, ok := cause.(net.Error); ok {
+		return netErr.Timeout()
+	}
+	if urlErr, ok := cause.(*url.Error); ok {
+		return urlErr.Timeout() || urlErr.Err == context.DeadlineExceeded
+	}
+	return false
+}
+
+// isOrderNotFound check if err means the queried order does not exist
+func isOrderNotFound(err error) bool {
+	apiErr, ok := errors.Cause(err).(*binance.APIError)
+	return ok && apiErr.Code == -2013
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// AutoInvestPlan define a recurring buy plan of Auto-Invest
+type AutoInvestPlan struct {
+	PlanID                 int64       `json:"planId"`
+	PlanType               string      `json:"planType"`
+	Status                 string      `json:"status"`
+	SourceAsset            string      `json:"sourceAsset"`
+	TargetAsset            string      `json:"targetAsset,omitempty"`
+	SubscriptionAmount     string      `json:"subscriptionAmount"`
+	SubscriptionCycle      string      `json:"subscriptionCycle"`
+	CreationDateTime       json.Number `json:"creationDateTime"`
+	FirstExecutionDateTime json.Number `json:"firstExecutionDateTime"`
+	NextExecutionDateTime  json.Number `json:"nextExecutionDateTime"`
+	TotalInvestedInUSD     string      `json:"totalInvestedInUSD"`
+	TotalTargetAmount      string      `json:"totalTargetAmount,omitempty"`
+	PnlInUSD               string      `json:"pnlInUSD"`
+	ROI                    string      `json:"roi"`
+	Details                []struct {
+		TargetAsset       string `json:"targetAsset"`
+		Percentage        string `json:"percentage"`
+		PurchasedAmount   string `json:"purchasedAmount"`
+		AveragePriceInUSD string `json:"averagePriceInUSD"`
+	} `json:"details,omitempty"`
+}
+
+// ListAutoInvestPlans list Auto-Invest plans of plan type: SINGLE, PORTFOLIO or INDEX
+func (account *Account) ListAutoInvestPlans(planType string) ([]*AutoInvestPlan, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		Plans []*AutoInvestPlan `json:"plans"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/lending/auto-invest/plan/list",
+		url.Values{"planType": {planType}}, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res.Plans, nil
+}
+
+// AutoInvestExecution define an execution of an Auto-Invest plan
+type AutoInvestExecution struct {
+	ID                  int64  `json:"id"`
+	PlanID              int64  `json:"planId"`
+	PlanType            string `json:"planType"`
+	PlanName            string `json:"planName"`
+	TransactionDateTime int64  `json:"transactionDateTime"`
+	TransactionStatus   string `json:"transactionStatus"`
+	FailedType          string `json:"failedType,omitempty"`
+	SourceAsset         string `json:"sourceAsset"`
+	SourceAssetAmount   string `json:"sourceAssetAmount"`
+	TargetAsset         string `json:"targetAsset"`
+	TargetAssetAmount   string `json:"targetAssetAmount"`
+	ExecutionPrice      string `json:"executionPrice"`
+	TransactionFee      string `json:"transactionFee"`
+	TransactionFeeUnit  string `json:"transactionFeeUnit"`
+	SourceWallet        string `json:"sourceWallet"`
+}
+
+// ListAutoInvestExecutions list executions of Auto-Invest plans between startTime and endTime
+func (account *Account) ListAutoInvestExecutions(startTime, endTime int64) ([]*AutoInvestExecution, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var executions []*AutoInvestExecution
+	for current := 1; ; current++ {
+		params := url.Values{
+			"current": {strconv.Itoa(current)},
+			"size":    {"100"},
+		}
+		setTimeRange(params, 0, startTime, endTime)
+		res := new(struct {
+			List []*AutoInvestExecution `json:"list"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/lending/auto-invest/history/list",
+			params, true, res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		executions = append(executions, res.List...)
+		if len(res.List) < 100 {
+			return executions, nil
+		}
+	}
+}
+
+// AutoInvest define Auto-Invest plans of an account with execution history
+type AutoInvest struct {
+	Plans      []*AutoInvestPlan      `json:"plans"`
+	Executions []*AutoInvestExecution `json:"executions,omitempty"`
+}
+
+// autoInvestHistorySpan is the max time range of a request of Auto-Invest history
+const autoInvestHistorySpan = 30 * 24 * time.Hour
+
+// listAutoInvest list Auto-Invest plans of all plan types, with executions
+// of the page if history is set
+func listAutoInvest(history bool, page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		res := new(AutoInvest)
+		for _, planType := range []string{"SINGLE", "PORTFOLIO", "INDEX"} {
+			plans, err := account.ListAutoInvestPlans(planType)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			res.Plans = append(res.Plans, plans...)
+		}
+		if history {
+			err := page.Fetch(autoInvestHistorySpan, func(startTime, endTime int64) error {
+				executions, err := account.ListAutoInvestExecutions(startTime, endTime)
+				res.Executions = append(res.Executions, executions...)
+				return errors.Trace(err)
+			})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			res.Executions = page.Apply(res.Executions).([]*AutoInvestExecution)
+		}
+		return res, nil
+	})
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// AccountStats define trading performance of an account over a period
+type AccountStats struct {
+	Rank        int     `json:"rank,omitempty"`
+	Name        string  `json:"name"`
+	Trades      int     `json:"trades"`
+	Volume      float64 `json:"volume"`
+	Fees        float64 `json:"fees"`
+	RealizedPnL float64 `json:"realized_pnl"`
+	Return      float64 `json:"return"`
+	WinRate     float64 `json:"win_rate"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// compareStats compute stats of trades, all amounts are converted into quote
+// with graph at current prices, as symbols may have different quote assets.
+// Commissions paid in a third asset (e.g. BNB) are converted the same way, an
+// error is returned if any asset can not be converted.
+func compareStats(trades []*binance.TradeV3, symbols map[string]binance.Symbol,
+	graph *PriceGraph, quote string) (*AccountStats, error) {
+	rates := make(map[string]float64)
+	rate := func(asset string) (float64, error) {
+		if r, ok := rates[asset]; ok {
+			return r, nil
+		}
+		_, r, err := graph.Route(asset, quote)
+		if err != nil {
+			return 0, errors.Annotatef(err, "failed to value %s in %s", asset, quote)
+		}
+		rates[asset] = r
+		return r, nil
+	}
+	stats := new(AccountStats)
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].Time < trades[j].Time
+	})
+	positions := make(map[string]*Position)
+	var buyVolume float64
+	var wins, closes int
+	for _, trade := range trades {
+		info, ok := symbols[trade.Symbol]
+		if !ok {
+			return nil, errors.NotFoundf("symbol %s", trade.Symbol)
+		}
+		quoteRate, err := rate(info.QuoteAsset)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		price := StrToFloat(trade.Price)
+		qty := StrToFloat(trade.Quantity)
+		quoteQty := StrToFloat(trade.QuoteQuantity)
+		if quoteQty == 0 {
+			quoteQty = price * qty
+		}
+		stats.Trades++
+		stats.Volume += quoteQty * quoteRate
+
+		commission := StrToFloat(trade.Commission)
+		switch trade.CommissionAsset {
+		case info.QuoteAsset:
+			stats.Fees += commission * quoteRate
+		case info.BaseAsset:
+			stats.Fees += commission * price * quoteRate
+		default:
+			commissionRate, err := rate(trade.CommissionAsset)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			stats.Fees += commission * commissionRate
+		}
+
+		pos, ok := positions[trade.Symbol]
+		if !ok {
+			pos = &Position{Symbol: trade.Symbol}
+			positions[trade.Symbol] = pos
+		}
+		if trade.IsBuyer {
+			buyVolume += quoteQty * quoteRate
+		}
+		// fees are deducted from pnl as a whole, not from bought quantity
+		pnl, matched := pos.Apply(trade, "")
+		if matched <= 0 {
+			continue
+		}
+		stats.RealizedPnL += pnl * quoteRate
+		closes++
+		if pnl > 0 {
+			wins++
+		}
+	}
+	stats.RealizedPnL -= stats.Fees
+	if buyVolume > 0 {
+		stats.Return = stats.RealizedPnL / buyVolume * 100
+	}
+	if closes > 0 {
+		stats.WinRate = float64(wins) / float64(closes) * 100
+	}
+	return stats, nil
+}
+
+// rankStats sort stats by field in descending order and set rank.
+// fees is ranked ascending as lower fees are better.
+func rankStats(stats []*AccountStats, field string) error {
+	var less func(a, b *AccountStats) bool
+	switch strings.ToLower(field) {
+	case "return", "":
+		less = func(a, b *AccountStats) bool { return a.Return > b.Return }
+	case "volume":
+		less = func(a, b *AccountStats) bool { return a.Volume > b.Volume }
+	case "fees":
+		less = func(a, b *AccountStats) bool { return a.Fees < b.Fees }
+	case "win-rate":
+		less = func(a, b *AccountStats) bool { return a.WinRate > b.WinRate }
+	case "pnl":
+		less = func(a, b *AccountStats) bool { return a.RealizedPnL > b.RealizedPnL }
+	default:
+		return errors.Errorf("invalid sort field: %s", field)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return less(stats[i], stats[j])
+	})
+	for i, s := range stats {
+		s.Rank = i + 1
+	}
+	return nil
+}
+
+// compareAccounts rank accounts by stats of trades of symbols, amounts are
+// valued in quote. Accounts failed are listed after the ranked ones.
+func compareAccounts(symbols []string, startTime, endTime int64, sortBy, quote string) error {
+	quote = strings.ToUpper(quote)
+	if quote == "" {
+		return errors.New("quote required")
+	}
+	var symbolInfo map[string]binance.Symbol
+	var graph *PriceGraph
+	return accountsDo(func(account *Account) (interface{}, error) {
+		symbols := account.symbolsOr(symbols)
+		if len(symbols) == 0 {
+			return nil, errors.New("symbols required")
+		}
+		if symbolInfo == nil {
+			info, err := account.GetSymbols(nil)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if graph, err = account.NewPriceGraph(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			symbolInfo = info
+		}
+		var trades []*binance.TradeV3
+		for _, symbol := range symbols {
+			res, err := account.ListTrades(symbol, startTime, endTime)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			trades = append(trades, res...)
+		}
+		stats, err := compareStats(trades, symbolInfo, graph, quote)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		stats.Name = account.Name
+		stats.Volume = roundTotal(stats.Volume, quote)
+		stats.Fees = roundTotal(stats.Fees, quote)
+		stats.RealizedPnL = roundTotal(stats.RealizedPnL, quote)
+		return stats, nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		var stats, failed []*AccountStats
+		for name, res := range results {
+			s, ok := res.(*AccountStats)
+			if !ok {
+				failed = append(failed, &AccountStats{Name: name, Error: fmt.Sprint(res)})
+				continue
+			}
+			stats = append(stats, s)
+		}
+		byName := func(stats []*AccountStats) {
+			sort.Slice(stats, func(i, j int) bool {
+				return stats[i].Name < stats[j].Name
+			})
+		}
+		byName(stats)
+		byName(failed)
+		err := rankStats(stats, sortBy)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return append(stats, failed...), nil
+	})
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// symbolsFile is the file in data dir caching symbol names for completion
+const symbolsFile = "symbols.txt"
+
+// saveSymbols cache names of symbols of all prices for completion, the
+// cache is best effort so errors are ignored
+func saveSymbols(prices []*binance.SymbolPrice) {
+	names := make([]string, 0, len(prices))
+	for _, p := range prices {
+		names = append(names, p.Symbol)
+	}
+	sort.Strings(names)
+	if os.MkdirAll(dataDir, 0700) != nil {
+		return
+	}
+	ioutil.WriteFile(filepath.Join(dataDir, symbolsFile), []byte(strings.Join(names, "\n")+"\n"), 0600)
+}
+
+// completionNames return names completed for kind: accounts in keyfile,
+// or symbols cached by list-prices and configured for accounts
+func completionNames(kind string) ([]string, error) {
+	var names []string
+	switch kind {
+	case "accounts", "symbols":
+	default:
+		return nil, errors.Errorf("invalid kind of names: %s", kind)
+	}
+	// completion should not fail on a missing keyfile or prompt for a passphrase
+	keys, _ := loadKeys(keyfile, false)
+	for _, key := range keys {
+		if kind == "accounts" {
+			names = append(names, key.Name)
+		} else {
+			names = append(names, key.Symbols...)
+		}
+	}
+	if kind == "symbols" {
+		f, err := os.Open(filepath.Join(dataDir, symbolsFile))
+		if err == nil {
+			defer f.Close()
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				if name := strings.TrimSpace(scanner.Text()); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// completionFlag define a flag of a command for completion
+type completionFlag struct {
+	Names []string
+	Usage string
+	Value bool
+}
+
+// completionCommand define a command or subcommand path for completion
+type completionCommand struct {
+	Path     string
+	Usage    string
+	Commands []*completionCommand
+	Flags    []*completionFlag
+}
+
+// newCompletionFlags collect names and usages of visible flags
+func newCompletionFlags(flags []cli.Flag) []*completionFlag {
+	var res []*completionFlag
+	for _, flag := range flags {
+		f := &completionFlag{Value: true}
+		for _, name := range strings.Split(flag.GetName(), ",") {
+			f.Names = append(f.Names, strings.TrimSpace(name))
+		}
+		v := reflect.Indirect(reflect.ValueOf(flag))
+		if usage := v.FieldByName("Usage"); usage.IsValid() {
+			f.Usage = usage.String()
+		}
+		switch flag.(type) {
+		case cli.BoolFlag, cli.BoolTFlag, *cli.BoolFlag, *cli.BoolTFlag:
+			f.Value = false
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// newCompletionCommands collect visible commands under path
+func newCompletionCommands(commands []cli.Command, path string) []*completionCommand {
+	var res []*completionCommand
+	for _, cmd := range commands {
+		if cmd.Hidden {
+			continue
+		}
+		node := &completionCommand{
+			Path:  strings.TrimSpace(path + " " + cmd.Name),
+			Usage: cmd.Usage,
+			Flags: newCompletionFlags(cmd.VisibleFlags()),
+		}
+		node.Commands = newCompletionCommands(cmd.Subcommands, node.Path)
+		res = append(res, node)
+	}
+	return res
+}
+
+// walk call fn with command and all its subcommands
+func (c *completionCommand) walk(fn func(*completionCommand)) {
+	fn(c)
+	for _, cmd := range c.Commands {
+		cmd.walk(fn)
+	}
+}
+
+// words return subcommand names and flags completed after c
+func (c *completionCommand) words() []string {
+	var words []string
+	for _, cmd := range c.Commands {
+		words = append(words, cmd.Path[strings.LastIndex(cmd.Path, " ")+1:])
+	}
+	for _, flag := range c.Flags {
+		for _, name := range flag.Names {
+			words = append(words, flagPrefix(name)+name)
+		}
+	}
+	return words
+}
+
+// flagPrefix return the dashes of a flag name on command line
+func flagPrefix(name string) string {
+	if len(name) == 1 {
+		return "-"
+	}
+	return "--"
+}
+
+// completedNames return the kind of names completed for values of flag
+func completedNames(flag string) string {
+	switch flag {
+	case "name":
+		return "accounts"
+	case "symbol", "symbols":
+		return "symbols"
+	}
+	return ""
+}
+
+// writeBashCompletion write a bash completion script, zsh loads it
+// through bashcompinit
+func writeBashCompletion(b *strings.Builder, prog string, root *completionCommand) {
+	fn := "_" + strings.Replace(prog, "-", "_", -1)
+	fmt.Fprintf(b, "%s_words() {\n\tcase \"$1\" in\n", fn)
+	root.walk(func(c *completionCommand) {
+		fmt.Fprintf(b, "\t%q) echo %q ;;\n", c.Path, strings.Join(c.words(), " "))
+	})
+	b.WriteString("\t*) return 1 ;;\n\tesac\n}\n\n")
+	fmt.Fprintf(b, "%s() {\n", fn)
+	b.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" path=\"\" i\n")
+	b.WriteString("\tcase \"$prev\" in\n")
+	fmt.Fprintf(b, "\t--name) COMPREPLY=($(compgen -W \"$(%s complete-names accounts 2>/dev/null)\" -- \"$cur\")); return ;;\n", prog)
+	fmt.Fprintf(b, "\t--symbol|--symbols) COMPREPLY=($(compgen -W \"$(%s complete-names symbols 2>/dev/null)\" -- \"$cur\")); return ;;\n", prog)
+	b.WriteString("\tesac\n")
+	b.WriteString("\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(b, "\t\tif %s_words \"${path:+$path }${COMP_WORDS[i]}\" >/dev/null; then\n", fn)
+	b.WriteString("\t\t\tpath=\"${path:+$path }${COMP_WORDS[i]}\"\n\t\tfi\n\tdone\n")
+	fmt.Fprintf(b, "\tCOMPREPLY=($(compgen -W \"$(%s_words \"$path\")\" -- \"$cur\"))\n}\n\n", fn)
+	fmt.Fprintf(b, "complete -F %s %s\n", fn, prog)
+}
+
+// fishQuote quote s in single quotes of fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// writeFishCompletion write a fish completion script
+func writeFishCompletion(b *strings.Builder, prog string, root *completionCommand) {
+	fn := "__" + strings.Replace(prog, "-", "_", -1)
+	fmt.Fprintf(b, "set -g %s_paths", fn)
+	root.walk(func(c *completionCommand) {
+		if c.Path != "" {
+			b.WriteString(" " + fishQuote(c.Path))
+		}
+	})
+	b.WriteString("\n\n")
+	fmt.Fprintf(b, "function %s_is\n", fn)
+	b.WriteString("    set -l path ''\n")
+	b.WriteString("    for word in (commandline -opc)[2..-1]\n")
+	b.WriteString("        set -l next (string trim -- \"$path $word\")\n")
+	fmt.Fprintf(b, "        if contains -- $next $%s_paths\n", fn)
+	b.WriteString("            set path $next\n        end\n    end\n")
+	b.WriteString("    test \"$path\" = \"$argv[1]\"\nend\n\n")
+	fmt.Fprintf(b, "complete -c %s -f\n", prog)
+	root.walk(func(c *completionCommand) {
+		cond := fishQuote(fn + "_is " + fishQuote(c.Path))
+		for _, cmd := range c.Commands {
+			name := cmd.Path[strings.LastIndex(cmd.Path, " ")+1:]
+			fmt.Fprintf(b, "complete -c %s -n %s -a %s -d %s\n", prog, cond, name, fishQuote(cmd.Usage))
+		}
+		for _, flag := range c.Flags {
+			fmt.Fprintf(b, "complete -c %s -n %s", prog, cond)
+			for _, name := range flag.Names {
+				if len(name) == 1 {
+					fmt.Fprintf(b, " -s %s", name)
+				} else {
+					fmt.Fprintf(b, " -l %s", name)
+				}
+			}
+			if kind := completedNames(flag.Names[0]); kind != "" {
+				fmt.Fprintf(b, " -x -a %s", fishQuote(fmt.Sprintf("(%s complete-names %s 2>/dev/null)", prog, kind)))
+			} else if flag.Value {
+				b.WriteString(" -r")
+			}
+			fmt.Fprintf(b, " -d %s\n", fishQuote(flag.Usage))
+		}
+	})
+}
+
+// completionScript return the completion script of app for shell: bash, zsh or fish
+func completionScript(app *cli.App, shell string) (string, error) {
+	root := &completionCommand{
+		Commands: newCompletionCommands(app.Commands, ""),
+		Flags:    newCompletionFlags(app.VisibleFlags()),
+	}
+	var b strings.Builder
+	switch shell {
+	case "bash":
+		writeBashCompletion(&b, app.Name, root)
+	case "zsh":
+		fmt.Fprintf(&b, "#compdef %s\n\nautoload -U +X bashcompinit && bashcompinit\n\n", app.Name)
+		writeBashCompletion(&b, app.Name, root)
+	case "fish":
+		writeFishCompletion(&b, app.Name, root)
+	default:
+		return "", errors.Errorf("invalid shell: %s, must be bash, zsh or fish", shell)
+	}
+	return b.String(), nil
+}
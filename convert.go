@@ -0,0 +1,147 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// preferredAssets are tried first as intermediate assets when routing
+var preferredAssets = []string{"USDT", "BTC", "BNB", "ETH", "BUSD", "USDC"}
+
+// PriceGraph define conversion rates between assets built from market prices
+type PriceGraph struct {
+	rates map[string]map[string]float64
+}
+
+// NewPriceGraph build price graph from latest prices of trading symbols
+func (account *Account) NewPriceGraph() (*PriceGraph, error) {
+	symbols, err := account.GetSymbols(nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	prices, err := account.ListPrices("")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	graph := &PriceGraph{rates: make(map[string]map[string]float64)}
+	for _, p := range prices {
+		info, ok := symbols[p.Symbol]
+		if !ok || info.Status != "TRADING" {
+			continue
+		}
+		graph.AddRate(info.BaseAsset, info.QuoteAsset, StrToFloat(p.Price))
+	}
+	return graph, nil
+}
+
+// AddRate add rate of converting one from asset into to asset
+func (g *PriceGraph) AddRate(from, to string, rate float64) {
+	if rate <= 0 {
+		return
+	}
+	if g.rates[from] == nil {
+		g.rates[from] = make(map[string]float64)
+	}
+	if g.rates[to] == nil {
+		g.rates[to] = make(map[string]float64)
+	}
+	g.rates[from][to] = rate
+	g.rates[to][from] = 1 / rate
+}
+
+// neighbors return assets directly convertible from asset, preferred assets first
+func (g *PriceGraph) neighbors(asset string) []string {
+	var res []string
+	for _, a := range preferredAssets {
+		if _, ok := g.rates[asset][a]; ok {
+			res = append(res, a)
+		}
+	}
+	var others []string
+	for a := range g.rates[asset] {
+		if !StrContains(preferredAssets, a) {
+			others = append(others, a)
+		}
+	}
+	sort.Strings(others)
+	return append(res, others...)
+}
+
+// Route find the route with fewest hops from asset to asset,
+// return assets along the route and the overall rate
+func (g *PriceGraph) Route(from, to string) ([]string, float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return []string{from}, 1, nil
+	}
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 && prev[to] == "" {
+		asset := queue[0]
+		queue = queue[1:]
+		for _, next := range g.neighbors(asset) {
+			if _, ok := prev[next]; ok {
+				continue
+			}
+			prev[next] = asset
+			queue = append(queue, next)
+		}
+	}
+	if _, ok := prev[to]; !ok {
+		return nil, 0, errors.Errorf("no route from %s to %s", from, to)
+	}
+	route := []string{to}
+	for asset := prev[to]; asset != ""; asset = prev[asset] {
+		route = append([]string{asset}, route...)
+	}
+	rate := 1.0
+	for i := 1; i < len(route); i++ {
+		rate *= g.rates[route[i-1]][route[i]]
+	}
+	return route, rate, nil
+}
+
+// Convert convert amount of asset from into asset to
+func (g *PriceGraph) Convert(amount float64, from, to string) (float64, error) {
+	_, rate, err := g.Route(from, to)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return amount * rate, nil
+}
+
+// Conversion define result of converting an amount between assets
+type Conversion struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Amount float64  `json:"amount"`
+	Value  float64  `json:"value"`
+	Rate   float64  `json:"rate"`
+	Route  []string `json:"route"`
+}
+
+func convertPrice(from, to string, amount float64) error {
+	if from == "" || to == "" {
+		return errors.New("from and to assets required")
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		graph, err := account.NewPriceGraph()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		route, rate, err := graph.Route(from, to)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &Conversion{
+			From:   route[0],
+			To:     route[len(route)-1],
+			Amount: amount,
+			Value:  amount * rate,
+			Rate:   rate,
+			Route:  route,
+		}, nil
+	})
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// convertQuotesFile is the file in data dir of quotes got by convert-quote,
+// so convert-accept can pass their details to the pre-trade hook
+const convertQuotesFile = "convert_quotes.jsonl"
+
+// ConvertQuote define a quote of Binance Convert swapping assets at a fixed rate
+type ConvertQuote struct {
+	QuoteID        string `json:"quoteId"`
+	Ratio          string `json:"ratio"`
+	InverseRatio   string `json:"inverseRatio"`
+	ValidTimestamp int64  `json:"validTimestamp"`
+	FromAmount     string `json:"fromAmount"`
+	ToAmount       string `json:"toAmount"`
+}
+
+// GetConvertQuote get quote of converting fromAsset to toAsset, amount is of
+// fromAsset, or of toAsset if toAmount is set
+func (account *Account) GetConvertQuote(fromAsset, toAsset, amount string, toAmount bool,
+	validTime string) (*ConvertQuote, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"fromAsset": {strings.ToUpper(fromAsset)},
+		"toAsset":   {strings.ToUpper(toAsset)},
+	}
+	if toAmount {
+		params.Set("toAmount", amount)
+	} else {
+		params.Set("fromAmount", amount)
+	}
+	if validTime != "" {
+		params.Set("validTime", validTime)
+	}
+	res := new(ConvertQuote)
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/convert/getQuote", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// ConvertOrder define an accepted quote of Binance Convert
+type ConvertOrder struct {
+	OrderID     string `json:"orderId"`
+	CreateTime  int64  `json:"createTime"`
+	OrderStatus string `json:"orderStatus"`
+}
+
+// AcceptConvertQuote accept quote of quoteID before it expires
+func (account *Account) AcceptConvertQuote(quoteID string) (*ConvertOrder, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(ConvertOrder)
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/convert/acceptQuote",
+		url.Values{"quoteId": {quoteID}}, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// ConvertTrade define a completed conversion of Binance Convert
+type ConvertTrade struct {
+	QuoteID      string `json:"quoteId"`
+	OrderID      int64  `json:"orderId"`
+	OrderStatus  string `json:"orderStatus"`
+	FromAsset    string `json:"fromAsset"`
+	FromAmount   string `json:"fromAmount"`
+	ToAsset      string `json:"toAsset"`
+	ToAmount     string `json:"toAmount"`
+	Ratio        string `json:"ratio"`
+	InverseRatio string `json:"inverseRatio"`
+	CreateTime   int64  `json:"createTime"`
+}
+
+// ListConvertTrades list conversions between startTime and endTime, the
+// range can not exceed 30 days
+func (account *Account) ListConvertTrades(startTime, endTime int64) ([]*ConvertTrade, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	setTimeRange(params, 1000, startTime, endTime)
+	res := new(struct {
+		List []*ConvertTrade `json:"list"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/convert/tradeFlow", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res.List, nil
+}
+
+// convertQuoteRecord define a quote got by an account, saved for convert-accept
+type convertQuoteRecord struct {
+	Account   string `json:"account"`
+	FromAsset string `json:"fromAsset"`
+	ToAsset   string `json:"toAsset"`
+	*ConvertQuote
+}
+
+// findConvertQuote return the saved quote of quoteID got by account, nil if not found
+func findConvertQuote(account, quoteID string) (*convertQuoteRecord, error) {
+	var found *convertQuoteRecord
+	err := readRecords(convertQuotesFile, func(data []byte) error {
+		record := new(convertQuoteRecord)
+		if json.Unmarshal(data, record) == nil && record.ConvertQuote != nil &&
+			record.Account == account && record.QuoteID == quoteID {
+			found = record
+		}
+		return nil
+	})
+	return found, errors.Trace(err)
+}
+
+// checkConvertQuote call the pre-trade hook with the details of a quote
+func checkConvertQuote(account, fromAsset, toAsset string, quote *ConvertQuote) error {
+	return checkTrade(&TradeCheck{
+		Account:  account,
+		Market:   "convert",
+		Symbol:   strings.ToUpper(fromAsset + toAsset),
+		Side:     "SELL",
+		Type:     "QUOTE",
+		Quantity: quote.FromAmount,
+		Price:    quote.Ratio,
+	})
+}
+
+// convertQuote get quotes of accounts, and accept them at once if accept is
+// set and the pre-trade hook allows
+func convertQuote(fromAsset, toAsset, amount string, toAmount bool, validTime string, accept bool) error {
+	if fromAsset == "" || toAsset == "" || amount == "" {
+		return errors.New("from, to and amount required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		quote, err := account.GetConvertQuote(fromAsset, toAsset, amount, toAmount, validTime)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !accept {
+			err = appendRecord(convertQuotesFile, &convertQuoteRecord{
+				Account:      account.Name,
+				FromAsset:    strings.ToUpper(fromAsset),
+				ToAsset:      strings.ToUpper(toAsset),
+				ConvertQuote: quote,
+			})
+			if err != nil {
+				log.Print("failed to save quote: ", err)
+			}
+			return quote, nil
+		}
+		err = checkConvertQuote(account.Name, fromAsset, toAsset, quote)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		order, err := account.AcceptConvertQuote(quote.QuoteID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return map[string]interface{}{"quote": quote, "order": order}, nil
+	})
+}
+
+// convertAccept accept a quote got by convert-quote, after the pre-trade
+// hook allows its details
+func convertAccept(quoteID string) error {
+	if quoteID == "" {
+		return errors.New("quote id required")
+	}
+	if name == "" {
+		return errors.New("account name required as quotes are per account")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		record, err := findConvertQuote(account.Name, quoteID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if record == nil {
+			if preTradeHook != "" {
+				return nil, errors.NotFoundf("quote %s of convert-quote to check with the pre-trade hook", quoteID)
+			}
+		} else {
+			err = checkConvertQuote(account.Name, record.FromAsset, record.ToAsset, record.ConvertQuote)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		order, err := account.AcceptConvertQuote(quoteID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return order, nil
+	})
+}
+
+// convertHistorySpan is the max time range of a request of conversions
+const convertHistorySpan = 30 * 24 * time.Hour
+
+// listConvertTrades list conversions in 30 day windows, the last 30 days if
+// time range is not set
+func listConvertTrades(page *Page) error {
+	// the API requires a time range, so all windows are always fetched
+	windowed := *page
+	windowed.All = true
+	if windowed.StartTime == 0 {
+		endTime := windowed.EndTime
+		if endTime == 0 {
+			endTime = MilliTime(time.Now())
+		}
+		windowed.StartTime = endTime - int64(convertHistorySpan/time.Millisecond)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var trades []*ConvertTrade
+		err := windowed.Fetch(convertHistorySpan, func(startTime, endTime int64) error {
+			res, err := account.ListConvertTrades(startTime, endTime)
+			trades = append(trades, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return page.Apply(trades), nil
+	})
+}
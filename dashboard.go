@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// DashboardBalance define a balance of an asset of an account
+type DashboardBalance struct {
+	Account string `json:"account"`
+	Asset   string `json:"asset"`
+	Free    string `json:"free"`
+	Locked  string `json:"locked"`
+}
+
+// DashboardOrder define an open order of an account
+type DashboardOrder struct {
+	Account  string `json:"account"`
+	Symbol   string `json:"symbol"`
+	OrderID  int64  `json:"orderId"`
+	Side     string `json:"side"`
+	Type     string `json:"type"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	Filled   string `json:"filled"`
+	Status   string `json:"status"`
+	Time     int64  `json:"time"`
+}
+
+// dashboard hold live balances, open orders and prices of accounts,
+// updated from user data and ticker streams
+type dashboard struct {
+	sync.Mutex
+	balances map[string]map[string]*DashboardBalance
+	orders   map[string]map[int64]*DashboardOrder
+	prices   map[string]*PriceUpdate
+	changed  bool
+}
+
+// newDashboard create a dashboard of accounts with balances and open
+// orders from REST, symbols of open orders are added to symbols
+func newDashboard(accounts map[string]*Account, symbols []string) (*dashboard, []string, error) {
+	d := &dashboard{
+		balances: make(map[string]map[string]*DashboardBalance),
+		orders:   make(map[string]map[int64]*DashboardOrder),
+		prices:   make(map[string]*PriceUpdate),
+		changed:  true,
+	}
+	for _, account := range accounts {
+		err := account.UpdateBalances(nil)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "account %s", account.Name)
+		}
+		d.balances[account.Name] = make(map[string]*DashboardBalance)
+		d.setBalances(account.Name, account.Balances)
+		orders, err := account.ListOpenOrders("")
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "account %s", account.Name)
+		}
+		d.orders[account.Name] = make(map[int64]*DashboardOrder)
+		for _, o := range orders {
+			d.orders[account.Name][o.OrderID] = &DashboardOrder{
+				Account:  account.Name,
+				Symbol:   o.Symbol,
+				OrderID:  o.OrderID,
+				Side:     string(o.Side),
+				Type:     string(o.Type),
+				Price:    o.Price,
+				Quantity: o.OrigQuantity,
+				Filled:   o.ExecutedQuantity,
+				Status:   string(o.Status),
+				Time:     o.Time,
+			}
+			if !StrContains(symbols, o.Symbol) {
+				symbols = append(symbols, o.Symbol)
+			}
+		}
+		for _, symbol := range account.Symbols {
+			if !StrContains(symbols, symbol) {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return d, symbols, nil
+}
+
+// setBalances update balances of account, empty balances are removed
+func (d *dashboard) setBalances(account string, balances []binance.Balance) {
+	for _, b := range balances {
+		if StrToFloat(b.Free) == 0 && StrToFloat(b.Locked) == 0 {
+			delete(d.balances[account], b.Asset)
+			continue
+		}
+		d.balances[account][b.Asset] = &DashboardBalance{Account: account, Asset: b.Asset, Free: b.Free, Locked: b.Locked}
+	}
+}
+
+// HandleUserEvent apply an order update or balance change of a user data stream
+func (d *dashboard) HandleUserEvent(event interface{}) {
+	d.Lock()
+	defer d.Unlock()
+	switch e := event.(type) {
+	case *OrderUpdate:
+		orders := d.orders[e.Account]
+		if e.Status != "NEW" && e.Status != "PARTIALLY_FILLED" {
+			delete(orders, e.OrderID)
+		} else {
+			orders[e.OrderID] = &DashboardOrder{
+				Account:  e.Account,
+				Symbol:   e.Symbol,
+				OrderID:  e.OrderID,
+				Side:     e.Side,
+				Type:     e.Type,
+				Price:    e.Price,
+				Quantity: e.Quantity,
+				Filled:   e.FilledQuantity,
+				Status:   e.Status,
+				Time:     e.Time,
+			}
+		}
+	case *BalanceChange:
+		// deltas of balanceUpdate are followed by outboundAccountPosition
+		d.setBalances(e.Account, e.Balances)
+	}
+	d.changed = true
+}
+
+// HandlePrice apply a live price of a symbol
+func (d *dashboard) HandlePrice(update *PriceUpdate) {
+	d.Lock()
+	defer d.Unlock()
+	d.prices[update.Symbol] = update
+	d.changed = true
+}
+
+// Render return panes of prices, balances and open orders as tables,
+// false if nothing changed since the last render
+func (d *dashboard) Render() (string, bool, error) {
+	d.Lock()
+	defer d.Unlock()
+	if !d.changed {
+		return "", false, nil
+	}
+	d.changed = false
+	view := struct {
+		Prices     []*PriceUpdate      `json:"prices"`
+		Balances   []*DashboardBalance `json:"balances"`
+		OpenOrders []*DashboardOrder   `json:"open_orders"`
+	}{}
+	for _, p := range d.prices {
+		view.Prices = append(view.Prices, p)
+	}
+	sort.Slice(view.Prices, func(i, j int) bool {
+		return view.Prices[i].Symbol < view.Prices[j].Symbol
+	})
+	for _, balances := range d.balances {
+		for _, b := range balances {
+			view.Balances = append(view.Balances, b)
+		}
+	}
+	sort.Slice(view.Balances, func(i, j int) bool {
+		a, b := view.Balances[i], view.Balances[j]
+		return a.Account < b.Account || a.Account == b.Account && a.Asset < b.Asset
+	})
+	for _, orders := range d.orders {
+		for _, o := range orders {
+			view.OpenOrders = append(view.OpenOrders, o)
+		}
+	}
+	sort.Slice(view.OpenOrders, func(i, j int) bool {
+		a, b := view.OpenOrders[i], view.OpenOrders[j]
+		return a.Account < b.Account || a.Account == b.Account && a.Time < b.Time
+	})
+	data, err := json.Marshal(view)
+	if err != nil {
+		return "", false, errors.Trace(err)
+	}
+	v, err := decodeOrdered(data)
+	if err != nil {
+		return "", false, errors.Trace(err)
+	}
+	var b strings.Builder
+	writeTables(&b, "", v)
+	return b.String(), true, nil
+}
+
+// runDashboard draw live balances, open orders and prices of symbols
+// across accounts, redrawn at most every interval when streams update them
+func runDashboard(symbols []string, interval time.Duration) error {
+	accounts := findAccounts(name)
+	for i, symbol := range symbols {
+		symbols[i] = strings.ToUpper(symbol)
+	}
+	d, symbols, err := newDashboard(accounts, symbols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	listenKeys, err := startUserStreams(accounts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for key, account := range accounts {
+		go watchUserStream(account, listenKeys[key], d.HandleUserEvent)
+	}
+	if len(symbols) > 0 {
+		go func() {
+			err := watchTickers(symbols, d.HandlePrice)
+			if err != nil {
+				log.Print("failed to watch prices: ", err)
+			}
+		}()
+	}
+	screen := &refreshScreen{title: "dashboard", interval: interval, terminal: isTerminal()}
+	for {
+		text, changed, err := d.Render()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if changed {
+			screen.Draw(text)
+		}
+		time.Sleep(interval)
+	}
+}
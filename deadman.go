@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+func heartbeatFile() string {
+	return filepath.Join(dataDir, "heartbeat")
+}
+
+// heartbeat record current time as the latest operator heartbeat
+func heartbeat() error {
+	err := os.MkdirAll(dataDir, 0700)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	now := time.Now()
+	err = ioutil.WriteFile(heartbeatFile(), []byte(strconv.FormatInt(MilliTime(now), 10)), 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return print(map[string]string{"heartbeat": now.Format(time.RFC3339)})
+}
+
+// lastHeartbeat return time of latest heartbeat, zero time if none
+func lastHeartbeat() (time.Time, error) {
+	data, err := ioutil.ReadFile(heartbeatFile())
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, errors.Trace(err)
+	}
+	ms, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Annotate(err, "invalid heartbeat file")
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
+
+// runDeadman cancel all open orders of selected accounts when no heartbeat
+// is received within interval, futures positions are closed too if
+// flattenFutures is set. Accounts failed are retried on each check until
+// they succeed, the switch is armed again after a new heartbeat once it has
+// fired.
+func runDeadman(interval, checkInterval time.Duration, flattenFutures bool) error {
+	if interval <= 0 || checkInterval <= 0 {
+		return errors.New("interval and check interval must be positive")
+	}
+	err := heartbeat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("dead man's switch armed, interval: %s", interval)
+	var fired time.Time
+	// pending are accounts not cancelled yet since the switch fired
+	var pending map[string]*Account
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		last, err := lastHeartbeat()
+		if err != nil {
+			log.Print("failed to read heartbeat: ", err)
+			continue
+		}
+		if !fired.IsZero() {
+			if last.After(fired) {
+				fired, pending = time.Time{}, nil
+				log.Print("heartbeat received, dead man's switch armed again")
+				continue
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			log.Printf("retrying to cancel open orders of %d accounts", len(pending))
+		} else {
+			if time.Since(last) < interval {
+				continue
+			}
+			log.Printf("no heartbeat since %s, cancelling all open orders", last.Format(time.RFC3339))
+			fired, pending = time.Now(), make(map[string]*Account)
+			for k, v := range findAccounts(name) {
+				pending[k] = v
+			}
+		}
+		err = accountsRun(pending, func(account *Account) (interface{}, error) {
+			res, err := flattenAccount(account, flattenFutures)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			delete(pending, account.Name)
+			return res, nil
+		})
+		if err != nil {
+			log.Print("failed to cancel open orders: ", errors.ErrorStack(err))
+		}
+	}
+	return nil
+}
+
+// flattenAccount cancel all open orders of account, and close its futures
+// positions if flattenFutures is set
+func flattenAccount(account *Account, flattenFutures bool) (interface{}, error) {
+	orderIDs, err := account.CancelOpenOrders("")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !flattenFutures {
+		return orderIDs, nil
+	}
+	closeIDs, err := account.CloseFuturesPositions("", 100)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to close futures positions")
+	}
+	return map[string][]int64{"canceled": orderIDs, "closed_futures": closeIDs}, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// OrderBook define a local order book of a symbol merged from depth diffs,
+// quantities are keyed by price
+type OrderBook struct {
+	LastUpdateID int64
+	Bids         map[string]string
+	Asks         map[string]string
+}
+
+// newOrderBook create an order book from a depth snapshot
+func newOrderBook(depth *binance.DepthResponse) *OrderBook {
+	book := &OrderBook{
+		LastUpdateID: depth.LastUpdateID,
+		Bids:         make(map[string]string),
+		Asks:         make(map[string]string),
+	}
+	for _, bid := range depth.Bids {
+		book.Bids[bid.Price] = bid.Quantity
+	}
+	for _, ask := range depth.Asks {
+		book.Asks[ask.Price] = ask.Quantity
+	}
+	return book
+}
+
+// applyLevels merge levels into side of book, zero quantity removes the level
+func applyLevels(side map[string]string, levels [][]string) {
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		if StrToFloat(level[1]) == 0 {
+			delete(side, level[0])
+		} else {
+			side[level[0]] = level[1]
+		}
+	}
+}
+
+// topLevels return best n levels of side, highest first for bids
+func topLevels(side map[string]string, n int, bids bool) [][]string {
+	prices := make([]string, 0, len(side))
+	for price := range side {
+		prices = append(prices, price)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if bids {
+			return StrToFloat(prices[i]) > StrToFloat(prices[j])
+		}
+		return StrToFloat(prices[i]) < StrToFloat(prices[j])
+	})
+	if n > 0 && len(prices) > n {
+		prices = prices[:n]
+	}
+	levels := make([][]string, len(prices))
+	for i, price := range prices {
+		levels[i] = []string{price, side[price]}
+	}
+	return levels
+}
+
+// depthEvent define a diff event of symbol@depth stream
+type depthEvent struct {
+	Time    int64      `json:"E"`
+	Symbol  string     `json:"s"`
+	FirstID int64      `json:"U"`
+	FinalID int64      `json:"u"`
+	Bids    [][]string `json:"b"`
+	Asks    [][]string `json:"a"`
+}
+
+// localBook maintain an order book of symbol from diff events synced with
+// a REST snapshot, a new snapshot is fetched when updates are missed
+type localBook struct {
+	account *Account
+	symbol  string
+	book    *OrderBook
+	synced  bool
+}
+
+// Apply merge event into the book, false is returned if the book is not
+// ready to be shown yet
+func (l *localBook) Apply(event *depthEvent) (bool, error) {
+	if l.book == nil {
+		ctx, cancel := newContext()
+		defer cancel()
+		depth, err := l.account.NewDepthService().Symbol(l.symbol).Limit(1000).Do(ctx)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		l.book, l.synced = newOrderBook(depth), false
+	}
+	if event.FinalID <= l.book.LastUpdateID {
+		return false, nil
+	}
+	if (!l.synced && event.FirstID > l.book.LastUpdateID+1) ||
+		(l.synced && event.FirstID != l.book.LastUpdateID+1) {
+		log.Printf("missed depth updates of %s, resyncing snapshot", l.symbol)
+		l.book = nil
+		return false, nil
+	}
+	l.synced = true
+	applyLevels(l.book.Bids, event.Bids)
+	applyLevels(l.book.Asks, event.Asks)
+	l.book.LastUpdateID = event.FinalID
+	return true, nil
+}
+
+// DepthView define best levels of an order book with best bid/ask summary
+type DepthView struct {
+	Symbol    string     `json:"symbol"`
+	Time      int64      `json:"time,omitempty"`
+	BestBid   string     `json:"best_bid"`
+	BestAsk   string     `json:"best_ask"`
+	Spread    string     `json:"spread"`
+	SpreadBps float64    `json:"spread_bps"`
+	Bids      [][]string `json:"bids,omitempty"`
+	Asks      [][]string `json:"asks,omitempty"`
+}
+
+// newDepthView create a view of sorted levels, levels are dropped if summary is set
+func newDepthView(symbol string, time int64, bids, asks [][]string, summary bool) *DepthView {
+	view := &DepthView{Symbol: symbol, Time: time, Bids: bids, Asks: asks}
+	if len(bids) > 0 && len(asks) > 0 {
+		bid, ask := StrToFloat(bids[0][0]), StrToFloat(asks[0][0])
+		view.BestBid, view.BestAsk = bids[0][0], asks[0][0]
+		view.Spread = fmt.Sprintf("%.8f", ask-bid)
+		if mid := (bid + ask) / 2; mid > 0 {
+			view.SpreadBps = (ask - bid) / mid * 10000
+		}
+	}
+	if summary {
+		view.Bids, view.Asks = nil, nil
+	}
+	return view
+}
+
+// depthLevels convert levels of a depth response into price/quantity pairs
+func depthLevels(depth *binance.DepthResponse, levels int) (bids, asks [][]string) {
+	for i, bid := range depth.Bids {
+		if i < levels {
+			bids = append(bids, []string{bid.Price, bid.Quantity})
+		}
+	}
+	for i, ask := range depth.Asks {
+		if i < levels {
+			asks = append(asks, []string{ask.Price, ask.Quantity})
+		}
+	}
+	return bids, asks
+}
+
+// watchDepth print order books of symbols from partial depth streams of
+// levels 5, 10 or 20, or from local books merged from diff events if local
+// is set. Rate is the update speed of the streams: 100ms or 1000ms.
+func watchDepth(symbols []string, levels int, local bool, rate string, summary bool) error {
+	symbols, err := upperSymbols(symbols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if rate != "100ms" && rate != "1000ms" {
+		return errors.Errorf("invalid rate: %s", rate)
+	}
+	if !local && levels != 5 && levels != 10 && levels != 20 {
+		return errors.Errorf("invalid levels of partial depth: %d", levels)
+	}
+	kind := fmt.Sprintf("depth%d", levels)
+	if local {
+		kind = "depth"
+	}
+	if rate == "100ms" {
+		kind += "@100ms"
+	}
+	account := publicAccount()
+	books := make(map[string]*localBook)
+	for _, symbol := range symbols {
+		books[symbol] = &localBook{account: account, symbol: symbol}
+	}
+	return watchMarket(marketStreams(symbols, kind), func(stream string, data []byte) {
+		// partial depth events carry no symbol
+		symbol := streamSymbol(stream)
+		if !local {
+			depth := new(struct {
+				Bids [][]string `json:"bids"`
+				Asks [][]string `json:"asks"`
+			})
+			err := json.Unmarshal(data, depth)
+			if err != nil {
+				log.Print("failed to decode depth event: ", err)
+				return
+			}
+			printEvent(newDepthView(symbol, 0, depth.Bids, depth.Asks, summary))
+			return
+		}
+		event := new(depthEvent)
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			log.Print("failed to decode depth event: ", err)
+			return
+		}
+		book, ok := books[symbol]
+		if !ok {
+			return
+		}
+		ready, err := book.Apply(event)
+		if err != nil {
+			log.Print("failed to sync order book: ", err)
+			return
+		}
+		if ready {
+			printEvent(newDepthView(symbol, event.Time, topLevels(book.book.Bids, levels, true),
+				topLevels(book.book.Asks, levels, false), summary))
+		}
+	}, func(stream string, data interface{}) {
+		if depth, ok := data.(*binance.DepthResponse); ok {
+			bids, asks := depthLevels(depth, levels)
+			printEvent(newDepthView(streamSymbol(stream), 0, bids, asks, summary))
+		}
+	}, func() {
+		// updates were missed while disconnected
+		for _, book := range books {
+			book.book = nil
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// earnPageSize is the max number of Simple Earn positions per page
+const earnPageSize = 100
+
+// FlexibleEarnPosition define a Simple Earn flexible position
+type FlexibleEarnPosition struct {
+	Asset                      string `json:"asset"`
+	ProductID                  string `json:"productId"`
+	TotalAmount                string `json:"totalAmount"`
+	LatestAnnualPercentageRate string `json:"latestAnnualPercentageRate"`
+	YesterdayRealTimeRewards   string `json:"yesterdayRealTimeRewards"`
+	CumulativeBonusRewards     string `json:"cumulativeBonusRewards"`
+	CumulativeRealTimeRewards  string `json:"cumulativeRealTimeRewards"`
+	CumulativeTotalRewards     string `json:"cumulativeTotalRewards"`
+	CanRedeem                  bool   `json:"canRedeem"`
+	AutoSubscribe              bool   `json:"autoSubscribe"`
+}
+
+// LockedEarnPosition define a Simple Earn locked position
+type LockedEarnPosition struct {
+	PositionID     json.Number `json:"positionId"`
+	ProjectID      string      `json:"projectId"`
+	Asset          string      `json:"asset"`
+	Amount         string      `json:"amount"`
+	PurchaseTime   json.Number `json:"purchaseTime"`
+	Duration       json.Number `json:"duration"`
+	AccrualDays    json.Number `json:"accrualDays"`
+	RewardAsset    string      `json:"rewardAsset"`
+	APY            string      `json:"APY"`
+	RewardAmt      string      `json:"rewardAmt"`
+	NextPay        string      `json:"nextPay"`
+	NextPayDate    json.Number `json:"nextPayDate"`
+	RewardsEndDate json.Number `json:"rewardsEndDate"`
+	DeliverDate    json.Number `json:"deliverDate"`
+	RedeemingAmt   string      `json:"redeemingAmt"`
+	CanRedeemEarly bool        `json:"canRedeemEarly"`
+	AutoSubscribe  bool        `json:"autoSubscribe"`
+	Type           string      `json:"type"`
+	Status         string      `json:"status"`
+}
+
+// earnPositions list all pages of Simple Earn positions of endpoint, rows of
+// each page are passed to appendRows which returns the number of rows
+func (account *Account) earnPositions(endpoint, asset string, appendRows func(rows json.RawMessage) (int, error)) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	for current := 1; ; current++ {
+		params := url.Values{
+			"current": {strconv.Itoa(current)},
+			"size":    {strconv.Itoa(earnPageSize)},
+		}
+		if asset != "" {
+			params.Set("asset", strings.ToUpper(asset))
+		}
+		res := new(struct {
+			Rows json.RawMessage `json:"rows"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, endpoint, params, true, res)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		n, err := appendRows(res.Rows)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if n < earnPageSize {
+			return nil
+		}
+	}
+}
+
+// ListFlexibleEarn list Simple Earn flexible positions of asset, all assets if empty
+func (account *Account) ListFlexibleEarn(asset string) ([]*FlexibleEarnPosition, error) {
+	var positions []*FlexibleEarnPosition
+	err := account.earnPositions("/sapi/v1/simple-earn/flexible/position", asset,
+		func(rows json.RawMessage) (int, error) {
+			var page []*FlexibleEarnPosition
+			err := json.Unmarshal(rows, &page)
+			positions = append(positions, page...)
+			return len(page), errors.Trace(err)
+		})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return positions, nil
+}
+
+// ListLockedEarn list Simple Earn locked positions of asset, all assets if empty
+func (account *Account) ListLockedEarn(asset string) ([]*LockedEarnPosition, error) {
+	var positions []*LockedEarnPosition
+	err := account.earnPositions("/sapi/v1/simple-earn/locked/position", asset,
+		func(rows json.RawMessage) (int, error) {
+			var page []*LockedEarnPosition
+			err := json.Unmarshal(rows, &page)
+			positions = append(positions, page...)
+			return len(page), errors.Trace(err)
+		})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return positions, nil
+}
+
+// EarnBalances define Simple Earn positions of an account with amounts and
+// accrued rewards by asset
+type EarnBalances struct {
+	Flexible []*FlexibleEarnPosition `json:"flexible"`
+	Locked   []*LockedEarnPosition   `json:"locked"`
+	Amounts  map[string]float64      `json:"amounts"`
+	Rewards  map[string]float64      `json:"rewards"`
+}
+
+// GetEarnBalances get flexible and locked Simple Earn positions of asset, all assets if empty
+func (account *Account) GetEarnBalances(asset string) (*EarnBalances, error) {
+	flexible, err := account.ListFlexibleEarn(asset)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	locked, err := account.ListLockedEarn(asset)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	balances := &EarnBalances{
+		Flexible: flexible,
+		Locked:   locked,
+		Amounts:  make(map[string]float64),
+		Rewards:  make(map[string]float64),
+	}
+	for _, p := range flexible {
+		balances.Amounts[p.Asset] += StrToFloat(p.TotalAmount)
+		balances.Rewards[p.Asset] += StrToFloat(p.CumulativeTotalRewards)
+	}
+	for _, p := range locked {
+		balances.Amounts[p.Asset] += StrToFloat(p.Amount)
+		balances.Rewards[p.RewardAsset] += StrToFloat(p.RewardAmt)
+	}
+	for asset, amount := range balances.Amounts {
+		balances.Amounts[asset] = roundTotal(amount, asset)
+	}
+	for asset, reward := range balances.Rewards {
+		balances.Rewards[asset] = roundTotal(reward, asset)
+	}
+	return balances, nil
+}
+
+func listEarnBalances(asset string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		balances, err := account.GetEarnBalances(asset)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return balances, nil
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const executionsFile = "executions.jsonl"
+
+// Execution define an order submitted by the CLI with its arrival price
+type Execution struct {
+	Account    string  `json:"account"`
+	Strategy   string  `json:"strategy"`
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	OrderID    int64   `json:"order_id"`
+	ArrivalMid float64 `json:"arrival_mid"`
+	Time       int64   `json:"time"`
+}
+
+// arrivalMid return mid price of symbol at submission time, 0 if unavailable
+func (account *Account) arrivalMid(symbol string) float64 {
+	ticker, err := account.GetBookTicker(symbol)
+	if err != nil {
+		log.Print("failed to get arrival price: ", err)
+		return 0
+	}
+	return (StrToFloat(ticker.BidPrice) + StrToFloat(ticker.AskPrice)) / 2
+}
+
+// recordExecution save submitted order for execution quality report
+func recordExecution(execution *Execution) {
+	if execution.ArrivalMid == 0 {
+		return
+	}
+	execution.Time = MilliTime(time.Now())
+	err := appendRecord(executionsFile, execution)
+	if err != nil {
+		log.Print("failed to record execution: ", err)
+	}
+}
+
+// ExecutionQuality define implementation shortfall of a group of orders
+type ExecutionQuality struct {
+	Account       string  `json:"account"`
+	Strategy      string  `json:"strategy"`
+	Orders        int     `json:"orders"`
+	FilledOrders  int     `json:"filled_orders"`
+	Notional      float64 `json:"notional"`
+	ShortfallBps  float64 `json:"shortfall_bps"`
+	ShortfallCost float64 `json:"shortfall_cost"`
+}
+
+// executionReport compare fill prices of recorded orders with arrival mid prices.
+// Shortfall is positive when the fills are worse than the arrival price.
+func executionReport(strategy string, startTime int64) error {
+	executions := make(map[string][]*Execution)
+	err := readRecords(executionsFile, func(data []byte) error {
+		execution := new(Execution)
+		err := json.Unmarshal(data, execution)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if execution.Time < startTime || (strategy != "" && execution.Strategy != strategy) {
+			return nil
+		}
+		executions[execution.Account] = append(executions[execution.Account], execution)
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		groups := make(map[string]*ExecutionQuality)
+		for _, execution := range executions[account.Name] {
+			q, ok := groups[execution.Strategy]
+			if !ok {
+				q = &ExecutionQuality{Account: account.Name, Strategy: execution.Strategy}
+				groups[execution.Strategy] = q
+			}
+			q.Orders++
+			order, err := account.GetOrder(execution.Symbol, execution.OrderID)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			qty := StrToFloat(order.ExecutedQuantity)
+			notional := StrToFloat(order.CummulativeQuoteQuantity)
+			if qty == 0 || notional == 0 {
+				continue
+			}
+			sign := 1.0
+			if strings.ToUpper(execution.Side) == "SELL" {
+				sign = -1
+			}
+			q.FilledOrders++
+			q.Notional += notional
+			q.ShortfallCost += sign * (notional - qty*execution.ArrivalMid)
+		}
+		var res []*ExecutionQuality
+		for _, q := range groups {
+			if q.Notional > 0 {
+				q.ShortfallBps = q.ShortfallCost / q.Notional * 10000
+			}
+			q.Notional = roundTotal(q.Notional, "")
+			q.ShortfallCost = roundTotal(q.ShortfallCost, "")
+			res = append(res, q)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			return res[i].Strategy < res[j].Strategy
+		})
+		return res, nil
+	})
+}
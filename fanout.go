@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// fan-out of an action across accounts, set by commands submitting
+// identical orders to many accounts
+var (
+	// fanOutStagger is the delay between accounts
+	fanOutStagger time.Duration
+	// fanOutJitter is the max random delay added to fanOutStagger
+	fanOutJitter time.Duration
+	// fanOutShuffle randomizes the order of accounts, otherwise sorted by name
+	fanOutShuffle bool
+
+	fanOutRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// fanOutOrder return accounts in order of fan-out
+func fanOutOrder(accounts map[string]*Account) []*Account {
+	res := make([]*Account, 0, len(accounts))
+	for _, account := range accounts {
+		res = append(res, account)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	if fanOutShuffle {
+		fanOutRand.Shuffle(len(res), func(i, j int) {
+			res[i], res[j] = res[j], res[i]
+		})
+	}
+	return res
+}
+
+// fanOutWait wait before running action of the i-th account
+func fanOutWait(i int) {
+	if i == 0 || (fanOutStagger <= 0 && fanOutJitter <= 0) {
+		return
+	}
+	delay := fanOutStagger
+	if fanOutJitter > 0 {
+		delay += time.Duration(fanOutRand.Int63n(int64(fanOutJitter)))
+	}
+	log.Printf("waiting %s before next account", delay)
+	time.Sleep(delay)
+}
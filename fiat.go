@@ -0,0 +1,181 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// fiatPageSize is the max number of fiat history records per page
+const fiatPageSize = 500
+
+// FiatOrder define a fiat deposit or withdrawal through a bank or card
+type FiatOrder struct {
+	Type            string `json:"type"`
+	OrderNo         string `json:"orderNo"`
+	FiatCurrency    string `json:"fiatCurrency"`
+	IndicatedAmount string `json:"indicatedAmount"`
+	Amount          string `json:"amount"`
+	TotalFee        string `json:"totalFee"`
+	Method          string `json:"method"`
+	Status          string `json:"status"`
+	CreateTime      int64  `json:"createTime"`
+	UpdateTime      int64  `json:"updateTime"`
+}
+
+// FiatPayment define a crypto purchase or sale paid with fiat
+type FiatPayment struct {
+	Type           string `json:"type"`
+	OrderNo        string `json:"orderNo"`
+	SourceAmount   string `json:"sourceAmount"`
+	FiatCurrency   string `json:"fiatCurrency"`
+	ObtainAmount   string `json:"obtainAmount"`
+	CryptoCurrency string `json:"cryptoCurrency"`
+	TotalFee       string `json:"totalFee"`
+	Price          string `json:"price"`
+	Status         string `json:"status"`
+	PaymentMethod  string `json:"paymentMethod"`
+	CreateTime     int64  `json:"createTime"`
+	UpdateTime     int64  `json:"updateTime"`
+}
+
+// fiatParams return params of a page of fiat history, fiat endpoints use
+// beginTime instead of startTime
+func fiatParams(transactionType string, page int, beginTime, endTime int64) url.Values {
+	params := url.Values{
+		"transactionType": {transactionType},
+		"page":            {strconv.Itoa(page)},
+		"rows":            {strconv.Itoa(fiatPageSize)},
+	}
+	if beginTime > 0 {
+		params.Set("beginTime", strconv.FormatInt(beginTime, 10))
+	}
+	if endTime > 0 {
+		params.Set("endTime", strconv.FormatInt(endTime, 10))
+	}
+	return params
+}
+
+// ListFiatOrders list fiat deposits, or withdrawals if withdraw is set
+func (account *Account) ListFiatOrders(withdraw bool, beginTime, endTime int64) ([]*FiatOrder, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	transactionType, typ := "0", "deposit"
+	if withdraw {
+		transactionType, typ = "1", "withdraw"
+	}
+	var orders []*FiatOrder
+	for page := 1; ; page++ {
+		res := new(struct {
+			Data []*FiatOrder `json:"data"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/fiat/orders",
+			fiatParams(transactionType, page, beginTime, endTime), true, res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, order := range res.Data {
+			order.Type = typ
+		}
+		orders = append(orders, res.Data...)
+		if len(res.Data) < fiatPageSize {
+			return orders, nil
+		}
+	}
+}
+
+// ListFiatPayments list crypto purchases with fiat, or sales if sell is set
+func (account *Account) ListFiatPayments(sell bool, beginTime, endTime int64) ([]*FiatPayment, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	transactionType, typ := "0", "buy"
+	if sell {
+		transactionType, typ = "1", "sell"
+	}
+	var payments []*FiatPayment
+	for page := 1; ; page++ {
+		res := new(struct {
+			Data []*FiatPayment `json:"data"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/fiat/payments",
+			fiatParams(transactionType, page, beginTime, endTime), true, res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, payment := range res.Data {
+			payment.Type = typ
+		}
+		payments = append(payments, res.Data...)
+		if len(res.Data) < fiatPageSize {
+			return payments, nil
+		}
+	}
+}
+
+// FiatHistory define fiat deposits, withdrawals and payments of an account
+// with total fees by fiat currency
+type FiatHistory struct {
+	Orders    []*FiatOrder       `json:"orders,omitempty"`
+	Payments  []*FiatPayment     `json:"payments,omitempty"`
+	TotalFees map[string]float64 `json:"total_fees"`
+}
+
+// fiatHistorySpan is the max time range of a request of fiat history
+const fiatHistorySpan = 90 * 24 * time.Hour
+
+// listFiatHistory list fiat history of types: deposit, withdraw, buy, sell,
+// all types if empty. Total fees are of the records listed.
+func listFiatHistory(types []string, page *Page) error {
+	if len(types) == 0 {
+		types = []string{"deposit", "withdraw", "buy", "sell"}
+	}
+	for _, typ := range types {
+		if !StrContains([]string{"deposit", "withdraw", "buy", "sell"}, strings.ToLower(typ)) {
+			return errors.Errorf("invalid fiat history type: %s", typ)
+		}
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		history := &FiatHistory{TotalFees: make(map[string]float64)}
+		for _, typ := range types {
+			typ = strings.ToLower(typ)
+			err := page.Fetch(fiatHistorySpan, func(startTime, endTime int64) error {
+				switch typ {
+				case "deposit", "withdraw":
+					orders, err := account.ListFiatOrders(typ == "withdraw", startTime, endTime)
+					history.Orders = append(history.Orders, orders...)
+					return errors.Trace(err)
+				default:
+					payments, err := account.ListFiatPayments(typ == "sell", startTime, endTime)
+					history.Payments = append(history.Payments, payments...)
+					return errors.Trace(err)
+				}
+			})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		sort.SliceStable(history.Orders, func(i, j int) bool {
+			return history.Orders[i].CreateTime < history.Orders[j].CreateTime
+		})
+		sort.SliceStable(history.Payments, func(i, j int) bool {
+			return history.Payments[i].CreateTime < history.Payments[j].CreateTime
+		})
+		history.Orders = page.Apply(history.Orders).([]*FiatOrder)
+		history.Payments = page.Apply(history.Payments).([]*FiatPayment)
+		for _, order := range history.Orders {
+			history.TotalFees[order.FiatCurrency] += StrToFloat(order.TotalFee)
+		}
+		for _, payment := range history.Payments {
+			history.TotalFees[payment.FiatCurrency] += StrToFloat(payment.TotalFee)
+		}
+		for currency, fee := range history.TotalFees {
+			history.TotalFees[currency] = roundTotal(fee, currency)
+		}
+		return history, nil
+	})
+}
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// PremiumIndex define mark price, index price and funding of a futures symbol
+type PremiumIndex struct {
+	Symbol               string `json:"symbol"`
+	MarkPrice            string `json:"markPrice"`
+	IndexPrice           string `json:"indexPrice"`
+	EstimatedSettlePrice string `json:"estimatedSettlePrice"`
+	LastFundingRate      string `json:"lastFundingRate"`
+	NextFundingTime      int64  `json:"nextFundingTime"`
+	InterestRate         string `json:"interestRate"`
+	Time                 int64  `json:"time"`
+}
+
+// ListPremiumIndex list mark price and funding of futures symbol, all symbols if empty
+func (account *Account) ListPremiumIndex(symbol string) ([]*PremiumIndex, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	if symbol != "" {
+		index := new(PremiumIndex)
+		err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/premiumIndex",
+			url.Values{"symbol": {symbol}}, false, index)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []*PremiumIndex{index}, nil
+	}
+	var res []*PremiumIndex
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/premiumIndex", nil, false, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// FuturesPrice define latest price of a futures symbol
+type FuturesPrice struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+	Time   int64  `json:"time"`
+}
+
+// ListFuturesPrices list latest prices of futures symbols
+func (account *Account) ListFuturesPrices() ([]*FuturesPrice, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var res []*FuturesPrice
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/ticker/price", nil, false, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// OpenInterest define open interest of a futures symbol
+type OpenInterest struct {
+	Symbol               string `json:"symbol"`
+	SumOpenInterest      string `json:"sumOpenInterest"`
+	SumOpenInterestValue string `json:"sumOpenInterestValue,omitempty"`
+	Timestamp            int64  `json:"timestamp"`
+}
+
+// GetOpenInterest get current open interest of futures symbol
+func (account *Account) GetOpenInterest(symbol string) (*OpenInterest, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		Symbol       string `json:"symbol"`
+		OpenInterest string `json:"openInterest"`
+		Time         int64  `json:"time"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/openInterest",
+		url.Values{"symbol": {symbol}}, false, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &OpenInterest{
+		Symbol:          res.Symbol,
+		SumOpenInterest: res.OpenInterest,
+		Timestamp:       res.Time,
+	}, nil
+}
+
+// ListOpenInterestHistory list open interest history of futures symbol by period:
+// 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d
+func (account *Account) ListOpenInterestHistory(symbol, period string, limit int,
+	startTime, endTime int64) ([]*OpenInterest, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{"symbol": {symbol}, "period": {period}}
+	setTimeRange(params, limit, startTime, endTime)
+	var res []*OpenInterest
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/futures/data/openInterestHist",
+		params, false, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// long/short ratio kinds and their endpoints
+var longShortRatioEndpoints = map[string]string{
+	"top-account":  "/futures/data/topLongShortAccountRatio",
+	"top-position": "/futures/data/topLongShortPositionRatio",
+	"global":       "/futures/data/globalLongShortAccountRatio",
+}
+
+// LongShortRatio define long/short ratio of accounts or positions of a futures symbol
+type LongShortRatio struct {
+	Symbol         string `json:"symbol"`
+	LongShortRatio string `json:"longShortRatio"`
+	LongAccount    string `json:"longAccount"`
+	ShortAccount   string `json:"shortAccount"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+// ListLongShortRatio list long/short ratio of futures symbol by period, kind is
+// top-account, top-position or global
+func (account *Account) ListLongShortRatio(kind, symbol, period string, limit int,
+	startTime, endTime int64) ([]*LongShortRatio, error) {
+	endpoint, ok := longShortRatioEndpoints[kind]
+	if !ok {
+		return nil, errors.Errorf("invalid long/short ratio type: %s", kind)
+	}
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{"symbol": {symbol}, "period": {period}}
+	setTimeRange(params, limit, startTime, endTime)
+	var res []*LongShortRatio
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, endpoint, params, false, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// TakerVolume define taker buy and sell volume of a futures symbol
+type TakerVolume struct {
+	BuySellRatio string `json:"buySellRatio"`
+	BuyVol       string `json:"buyVol"`
+	SellVol      string `json:"sellVol"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// ListTakerVolume list taker buy/sell volume of futures symbol by period
+func (account *Account) ListTakerVolume(symbol, period string, limit int,
+	startTime, endTime int64) ([]*TakerVolume, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{"symbol": {symbol}, "period": {period}}
+	setTimeRange(params, limit, startTime, endTime)
+	var res []*TakerVolume
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/futures/data/takerlongshortRatio",
+		params, false, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// FuturesBalance define balance of an asset in the USD-M futures wallet
+type FuturesBalance struct {
+	Asset              string `json:"asset"`
+	Balance            string `json:"balance"`
+	CrossWalletBalance string `json:"crossWalletBalance"`
+	CrossUnPnl         string `json:"crossUnPnl"`
+	AvailableBalance   string `json:"availableBalance"`
+	MaxWithdrawAmount  string `json:"maxWithdrawAmount"`
+	UpdateTime         int64  `json:"updateTime"`
+}
+
+// ListFuturesBalances list balances of the USD-M futures wallet
+func (account *Account) ListFuturesBalances() ([]*FuturesBalance, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var res []*FuturesBalance
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v2/balance", nil, true, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// FuturesPosition define a position of a USD-M futures symbol
+type FuturesPosition struct {
+	Symbol           string `json:"symbol"`
+	PositionSide     string `json:"positionSide"`
+	PositionAmt      string `json:"positionAmt"`
+	EntryPrice       string `json:"entryPrice"`
+	MarkPrice        string `json:"markPrice"`
+	UnRealizedProfit string `json:"unRealizedProfit"`
+	LiquidationPrice string `json:"liquidationPrice"`
+	Leverage         string `json:"leverage"`
+	MarginType       string `json:"marginType"`
+	IsolatedMargin   string `json:"isolatedMargin"`
+	IsolatedWallet   string `json:"isolatedWallet"`
+	Notional         string `json:"notional"`
+	UpdateTime       int64  `json:"updateTime"`
+}
+
+// ListFuturesPositions list open positions of symbol, all symbols if empty
+func (account *Account) ListFuturesPositions(symbol string) ([]*FuturesPosition, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", strings.ToUpper(symbol))
+	}
+	var res []*FuturesPosition
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v2/positionRisk", params, true, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var positions []*FuturesPosition
+	for _, position := range res {
+		if StrToFloat(position.PositionAmt) != 0 {
+			positions = append(positions, position)
+		}
+	}
+	return positions, nil
+}
+
+// FuturesOrder define an order of a USD-M futures symbol
+type FuturesOrder struct {
+	Symbol        string `json:"symbol"`
+	OrderID       int64  `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Side          string `json:"side"`
+	PositionSide  string `json:"positionSide"`
+	Type          string `json:"type"`
+	TimeInForce   string `json:"timeInForce"`
+	Price         string `json:"price"`
+	AvgPrice      string `json:"avgPrice"`
+	StopPrice     string `json:"stopPrice"`
+	OrigQty       string `json:"origQty"`
+	ExecutedQty   string `json:"executedQty"`
+	CumQuote      string `json:"cumQuote"`
+	ActivatePrice string `json:"activatePrice,omitempty"`
+	PriceRate     string `json:"priceRate,omitempty"`
+	ReduceOnly    bool   `json:"reduceOnly"`
+	ClosePosition bool   `json:"closePosition"`
+	Status        string `json:"status"`
+	Time          int64  `json:"time,omitempty"`
+	UpdateTime    int64  `json:"updateTime"`
+}
+
+// ListFuturesOpenOrders list open orders of symbol, all symbols if empty
+func (account *Account) ListFuturesOpenOrders(symbol string) ([]*FuturesOrder, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", strings.ToUpper(symbol))
+	}
+	var orders []*FuturesOrder
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/openOrders", params, true, &orders)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return orders, nil
+}
+
+// FuturesOrderRequest define a new order of a USD-M futures symbol
+type FuturesOrderRequest struct {
+	Symbol   string
+	Side     string
+	Type     string
+	Quantity string
+	Price    string
+	// StopPrice is the trigger price of STOP, TAKE_PROFIT and their MARKET types
+	StopPrice string
+	// CallbackRate and ActivationPrice define TRAILING_STOP_MARKET orders
+	CallbackRate    string
+	ActivationPrice string
+	// PositionSide is LONG or SHORT in hedge mode, reduceOnly is not sent
+	// with it as the API rejects the combination
+	PositionSide  string
+	ReduceOnly    bool
+	ClosePosition bool
+}
+
+// Validate normalize request and check required params of order type
+func (r *FuturesOrderRequest) Validate() error {
+	r.Symbol, r.Side, r.Type = strings.ToUpper(r.Symbol), strings.ToUpper(r.Side), strings.ToUpper(r.Type)
+	r.PositionSide = strings.ToUpper(r.PositionSide)
+	if r.Type == "" {
+		r.Type = "LIMIT"
+	}
+	if r.Symbol == "" {
+		return errors.New("symbol required")
+	}
+	if r.Side != "BUY" && r.Side != "SELL" {
+		return errors.Errorf("invalid side: %s", r.Side)
+	}
+	if r.PositionSide != "" && r.PositionSide != "LONG" && r.PositionSide != "SHORT" {
+		return errors.Errorf("invalid position side: %s", r.PositionSide)
+	}
+	var required []string
+	switch r.Type {
+	case "LIMIT":
+		required = []string{"quantity", "price"}
+	case "MARKET":
+		required = []string{"quantity"}
+	case "STOP", "TAKE_PROFIT":
+		required = []string{"quantity", "price", "stop-price"}
+	case "STOP_MARKET", "TAKE_PROFIT_MARKET":
+		required = []string{"stop-price"}
+		if !r.ClosePosition {
+			required = append(required, "quantity")
+		}
+	case "TRAILING_STOP_MARKET":
+		required = []string{"quantity", "callback-rate"}
+	default:
+		return errors.Errorf("invalid order type: %s", r.Type)
+	}
+	values := map[string]string{
+		"quantity":      r.Quantity,
+		"price":         r.Price,
+		"stop-price":    r.StopPrice,
+		"callback-rate": r.CallbackRate,
+	}
+	for _, name := range required {
+		if values[name] == "" {
+			return errors.Errorf("%s required by %s order", name, r.Type)
+		}
+	}
+	if r.ClosePosition {
+		if r.Type != "STOP_MARKET" && r.Type != "TAKE_PROFIT_MARKET" {
+			return errors.Errorf("close-position not supported by %s order", r.Type)
+		}
+		if r.ReduceOnly || r.Quantity != "" {
+			return errors.New("close-position can not be set with reduce-only or quantity")
+		}
+	}
+	return nil
+}
+
+// params return request params of order
+func (r *FuturesOrderRequest) params() url.Values {
+	params := url.Values{
+		"symbol":           {r.Symbol},
+		"side":             {r.Side},
+		"type":             {r.Type},
+		"newClientOrderId": {newClientOrderID()},
+	}
+	optional := map[string]string{
+		"quantity":        r.Quantity,
+		"price":           r.Price,
+		"stopPrice":       r.StopPrice,
+		"callbackRate":    r.CallbackRate,
+		"activationPrice": r.ActivationPrice,
+	}
+	for key, value := range optional {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	if r.Price != "" {
+		params.Set("timeInForce", "GTC")
+	}
+	if r.PositionSide != "" {
+		params.Set("positionSide", r.PositionSide)
+	} else if r.ReduceOnly {
+		params.Set("reduceOnly", "true")
+	}
+	if r.ClosePosition {
+		params.Set("closePosition", "true")
+	}
+	return params
+}
+
+// CreateFuturesOrder create an order of a USD-M futures symbol
+func (account *Account) CreateFuturesOrder(r *FuturesOrderRequest) (*FuturesOrder, error) {
+	err := r.Validate()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = checkTrade(&TradeCheck{
+		Account:  account.Name,
+		Market:   "futures",
+		Symbol:   r.Symbol,
+		Side:     r.Side,
+		Type:     r.Type,
+		Quantity: r.Quantity,
+		Price:    r.Price,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ctx, cancel := newContext()
+	defer cancel()
+	params := r.params()
+	res := new(FuturesOrder)
+	err = account.callAPI(ctx, http.MethodPost, futuresURL, "/fapi/v1/order", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// GetFuturesOrder get an order of a USD-M futures symbol
+func (account *Account) GetFuturesOrder(symbol string, orderID int64) (*FuturesOrder, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":  {strings.ToUpper(symbol)},
+		"orderId": {strconv.FormatInt(orderID, 10)},
+	}
+	res := new(FuturesOrder)
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/order", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// CancelFuturesOrder cancel an order of a USD-M futures symbol
+func (account *Account) CancelFuturesOrder(symbol string, orderID int64) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":  {strings.ToUpper(symbol)},
+		"orderId": {strconv.FormatInt(orderID, 10)},
+	}
+	err := account.callAPI(ctx, http.MethodDelete, futuresURL, "/fapi/v1/order", params, true, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// CancelFuturesOpenOrders cancel open futures orders of symbol, all symbols if empty
+func (account *Account) CancelFuturesOpenOrders(symbol string) ([]int64, error) {
+	var canceledOrders []int64
+	orders, err := account.ListFuturesOpenOrders(symbol)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, order := range orders {
+		err = account.CancelFuturesOrder(order.Symbol, order.OrderID)
+		if err != nil {
+			return canceledOrders, errors.Trace(err)
+		}
+		canceledOrders = append(canceledOrders, order.OrderID)
+	}
+	return canceledOrders, nil
+}
+
+// FuturesLeverage define leverage of a USD-M futures symbol and the max
+// notional of positions allowed with it
+type FuturesLeverage struct {
+	Symbol           string `json:"symbol"`
+	Leverage         int    `json:"leverage"`
+	MaxNotionalValue string `json:"maxNotionalValue"`
+}
+
+// SetFuturesLeverage set initial leverage of symbol
+func (account *Account) SetFuturesLeverage(symbol string, leverage int) (*FuturesLeverage, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":   {strings.ToUpper(symbol)},
+		"leverage": {strconv.Itoa(leverage)},
+	}
+	res := new(FuturesLeverage)
+	err := account.callAPI(ctx, http.MethodPost, futuresURL, "/fapi/v1/leverage", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// SetFuturesMarginType set margin type of symbol: ISOLATED or CROSSED,
+// setting the current margin type is not an error
+func (account *Account) SetFuturesMarginType(symbol, marginType string) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":     {strings.ToUpper(symbol)},
+		"marginType": {strings.ToUpper(marginType)},
+	}
+	err := account.callAPI(ctx, http.MethodPost, futuresURL, "/fapi/v1/marginType", params, true, nil)
+	if apiErr, ok := errors.Cause(err).(*binance.APIError); ok && apiErr.Code == -4046 {
+		return nil
+	}
+	return errors.Trace(err)
+}
+
+// AdjustPositionMargin add amount of margin to the isolated position of
+// symbol, or remove it if reduce is set
+func (account *Account) AdjustPositionMargin(symbol, amount string, reduce bool) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol": {strings.ToUpper(symbol)},
+		"amount": {amount},
+		"type":   {"1"},
+	}
+	if reduce {
+		params.Set("type", "2")
+	}
+	err := account.callAPI(ctx, http.MethodPost, futuresURL, "/fapi/v1/positionMargin", params, true, nil)
+	return errors.Trace(err)
+}
+
+// futuresIncomePageSize is the max number of income records per request
+const futuresIncomePageSize = 1000
+
+// FuturesIncome define an income record of the USD-M futures account:
+// REALIZED_PNL, FUNDING_FEE, COMMISSION, TRANSFER ...
+type FuturesIncome struct {
+	Symbol     string      `json:"symbol"`
+	IncomeType string      `json:"incomeType"`
+	Income     string      `json:"income"`
+	Asset      string      `json:"asset"`
+	Info       string      `json:"info"`
+	Time       int64       `json:"time"`
+	TranID     json.Number `json:"tranId"`
+	TradeID    string      `json:"tradeId"`
+}
+
+// ListFuturesIncome list income of incomeType between startTime and endTime,
+// all types if empty, recent 7 days if startTime is not set
+func (account *Account) ListFuturesIncome(symbol, incomeType string, startTime, endTime int64) ([]*FuturesIncome, error) {
+	var incomes []*FuturesIncome
+	seen := make(map[string]bool)
+	for {
+		params := url.Values{}
+		if symbol != "" {
+			params.Set("symbol", strings.ToUpper(symbol))
+		}
+		if incomeType != "" {
+			params.Set("incomeType", strings.ToUpper(incomeType))
+		}
+		setTimeRange(params, futuresIncomePageSize, startTime, endTime)
+		var res []*FuturesIncome
+		ctx, cancel := newContext()
+		err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/income", params, true, &res)
+		cancel()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, income := range res {
+			// pages overlap at the time of the last record of previous page
+			key := income.TranID.String() + income.IncomeType + income.Symbol
+			if !seen[key] {
+				seen[key] = true
+				incomes = append(incomes, income)
+			}
+		}
+		if len(res) < futuresIncomePageSize {
+			return incomes, nil
+		}
+		last := res[len(res)-1].Time
+		if last <= startTime {
+			last = startTime + 1
+		}
+		startTime = last
+	}
+}
+
+// LeverageBracket define a notional tier of a USD-M futures symbol with its
+// maintenance margin ratio and maintenance amount
+type LeverageBracket struct {
+	Bracket          int     `json:"bracket"`
+	InitialLeverage  int     `json:"initialLeverage"`
+	NotionalCap      float64 `json:"notionalCap"`
+	NotionalFloor    float64 `json:"notionalFloor"`
+	MaintMarginRatio float64 `json:"maintMarginRatio"`
+	Cum              float64 `json:"cum"`
+}
+
+// ListLeverageBrackets list notional brackets of symbol, the API returns a
+// single object when symbol is sent
+func (account *Account) ListLeverageBrackets(symbol string) ([]*LeverageBracket, error) {
+	if symbol == "" {
+		return nil, errors.New("symbol required")
+	}
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		Symbol   string             `json:"symbol"`
+		Brackets []*LeverageBracket `json:"brackets"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/leverageBracket",
+		url.Values{"symbol": {strings.ToUpper(symbol)}}, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(res.Brackets) == 0 {
+		return nil, errors.NotFoundf("leverage brackets of %s", symbol)
+	}
+	return res.Brackets, nil
+}
+
+// ListFuturesMarginAssets list margin assets of USD-M futures symbols by
+// symbol
+func (account *Account) ListFuturesMarginAssets() (map[string]string, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		Symbols []struct {
+			Symbol      string `json:"symbol"`
+			MarginAsset string `json:"marginAsset"`
+		} `json:"symbols"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/exchangeInfo", nil, false, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	assets := make(map[string]string, len(res.Symbols))
+	for _, s := range res.Symbols {
+		assets[s.Symbol] = s.MarginAsset
+	}
+	return assets, nil
+}
+
+// GetFuturesSymbolFilters get filters of a USD-M futures symbol
+func (account *Account) GetFuturesSymbolFilters(symbol string) ([]map[string]interface{}, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		Symbols []struct {
+			Symbol  string                   `json:"symbol"`
+			Filters []map[string]interface{} `json:"filters"`
+		} `json:"symbols"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, futuresURL, "/fapi/v1/exchangeInfo", nil, false, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, s := range res.Symbols {
+		if s.Symbol == strings.ToUpper(symbol) {
+			return s.Filters, nil
+		}
+	}
+	return nil, errors.NotFoundf("futures symbol %s", symbol)
+}
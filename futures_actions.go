@@ -0,0 +1,563 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// MarkPrice define mark, index and last price of a futures symbol
+type MarkPrice struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	LastPrice       string `json:"lastPrice"`
+	Basis           string `json:"basis"`
+	BasisRate       string `json:"basisRate"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+}
+
+func listMarkPrices(symbols []string) error {
+	return runOnce(func(account *Account) (interface{}, error) {
+		symbol := ""
+		if len(symbols) == 1 {
+			symbol = symbols[0]
+		}
+		indexes, err := account.ListPremiumIndex(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		prices, err := account.ListFuturesPrices()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		lastPrices := make(map[string]string)
+		for _, p := range prices {
+			lastPrices[p.Symbol] = p.Price
+		}
+		var res []*MarkPrice
+		for _, index := range indexes {
+			if len(symbols) > 1 && !StrContains(symbols, index.Symbol) {
+				continue
+			}
+			last := StrToFloat(lastPrices[index.Symbol])
+			indexPrice := StrToFloat(index.IndexPrice)
+			markPrice := &MarkPrice{
+				Symbol:          index.Symbol,
+				MarkPrice:       index.MarkPrice,
+				IndexPrice:      index.IndexPrice,
+				LastPrice:       lastPrices[index.Symbol],
+				LastFundingRate: index.LastFundingRate,
+				NextFundingTime: index.NextFundingTime,
+			}
+			if indexPrice > 0 {
+				markPrice.Basis = fmt.Sprintf("%.8f", last-indexPrice)
+				markPrice.BasisRate = fmt.Sprintf("%.6f", (last-indexPrice)/indexPrice*100)
+			}
+			res = append(res, markPrice)
+		}
+		return res, nil
+	})
+}
+
+func listOpenInterest(symbol, period string, limit int, startTime, endTime int64, watch *MetricWatch) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	if watch.Interval > 0 {
+		return watch.Run("open interest of "+symbol, func(account *Account) (interface{}, float64, error) {
+			openInterest, err := account.GetOpenInterest(symbol)
+			if err != nil {
+				return nil, 0, errors.Trace(err)
+			}
+			return openInterest, StrToFloat(openInterest.SumOpenInterest), nil
+		})
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		if period == "" {
+			openInterest, err := account.GetOpenInterest(symbol)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			return []*OpenInterest{openInterest}, nil
+		}
+		history, err := account.ListOpenInterestHistory(symbol, period, limit, startTime, endTime)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return history, nil
+	})
+}
+
+func listLongShortRatio(kind, symbol, period string, limit int, startTime, endTime int64, watch *MetricWatch) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	if watch.Interval > 0 {
+		return watch.Run(kind+" long/short ratio of "+symbol, func(account *Account) (interface{}, float64, error) {
+			ratios, err := account.ListLongShortRatio(kind, symbol, period, 1, 0, 0)
+			if err != nil {
+				return nil, 0, errors.Trace(err)
+			}
+			if len(ratios) == 0 {
+				return nil, 0, errors.NotFoundf("long/short ratio of %s", symbol)
+			}
+			return ratios[0], StrToFloat(ratios[0].LongShortRatio), nil
+		})
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		ratios, err := account.ListLongShortRatio(kind, symbol, period, limit, startTime, endTime)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return ratios, nil
+	})
+}
+
+func listTakerVolume(symbol, period string, limit int, startTime, endTime int64, watch *MetricWatch) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	if watch.Interval > 0 {
+		return watch.Run("taker buy/sell ratio of "+symbol, func(account *Account) (interface{}, float64, error) {
+			volumes, err := account.ListTakerVolume(symbol, period, 1, 0, 0)
+			if err != nil {
+				return nil, 0, errors.Trace(err)
+			}
+			if len(volumes) == 0 {
+				return nil, 0, errors.NotFoundf("taker volume of %s", symbol)
+			}
+			return volumes[0], StrToFloat(volumes[0].BuySellRatio), nil
+		})
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		volumes, err := account.ListTakerVolume(symbol, period, limit, startTime, endTime)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return volumes, nil
+	})
+}
+
+// MetricWatch define polling of a market metric with alert thresholds
+type MetricWatch struct {
+	Interval time.Duration
+	Above    *float64
+	Below    *float64
+}
+
+// Run print latest value of metric every interval until interrupted, and
+// alert when the value crosses above or below the thresholds
+func (w *MetricWatch) Run(metric string, fetch func(*Account) (interface{}, float64, error)) error {
+	var alerted bool
+	return runOnce(func(account *Account) (interface{}, error) {
+		for {
+			row, value, err := fetch(account)
+			if err != nil {
+				log.Print("failed to fetch ", metric, ": ", err)
+			} else {
+				err = print(row)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				breached := (w.Above != nil && value > *w.Above) || (w.Below != nil && value < *w.Below)
+				if breached && !alerted {
+					log.Printf("ALERT: %s is %v, outside of thresholds", metric, value)
+				}
+				alerted = breached
+			}
+			time.Sleep(w.Interval)
+		}
+	})
+}
+
+// listFuturesBalances list non-zero balances of the USD-M futures wallet
+func listFuturesBalances() error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		balances, err := account.ListFuturesBalances()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var res []*FuturesBalance
+		for _, b := range balances {
+			if StrToFloat(b.Balance) != 0 || StrToFloat(b.CrossUnPnl) != 0 {
+				res = append(res, b)
+			}
+		}
+		return res, nil
+	})
+}
+
+// FuturesExposure define net and gross exposure of a symbol across accounts,
+// notional is signed by direction of positions
+type FuturesExposure struct {
+	Symbol           string  `json:"symbol"`
+	NetAmount        float64 `json:"net_amount"`
+	NetNotional      float64 `json:"net_notional"`
+	GrossNotional    float64 `json:"gross_notional"`
+	UnrealizedProfit float64 `json:"unrealized_profit"`
+	Accounts         int     `json:"accounts"`
+}
+
+// FuturesPositions define positions of accounts with aggregate exposure by symbol
+type FuturesPositions struct {
+	Accounts         map[string]interface{} `json:"accounts"`
+	Exposure         []*FuturesExposure     `json:"exposure"`
+	NetNotional      float64                `json:"net_notional"`
+	GrossNotional    float64                `json:"gross_notional"`
+	UnrealizedProfit float64                `json:"unrealized_profit"`
+}
+
+// listFuturesPositions list open positions of accounts with exposure
+// aggregated across accounts by symbol, largest gross notional first
+func listFuturesPositions(symbol string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		positions, err := account.ListFuturesPositions(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return positions, nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		res := &FuturesPositions{Accounts: results}
+		exposures := make(map[string]*FuturesExposure)
+		for _, r := range results {
+			positions, ok := r.([]*FuturesPosition)
+			if !ok {
+				continue
+			}
+			for _, p := range positions {
+				exposure, ok := exposures[p.Symbol]
+				if !ok {
+					exposure = &FuturesExposure{Symbol: p.Symbol}
+					exposures[p.Symbol] = exposure
+					res.Exposure = append(res.Exposure, exposure)
+				}
+				notional := StrToFloat(p.Notional)
+				exposure.NetAmount += StrToFloat(p.PositionAmt)
+				exposure.NetNotional += notional
+				exposure.GrossNotional += math.Abs(notional)
+				exposure.UnrealizedProfit += StrToFloat(p.UnRealizedProfit)
+				exposure.Accounts++
+			}
+		}
+		for _, exposure := range res.Exposure {
+			res.NetNotional += exposure.NetNotional
+			res.GrossNotional += exposure.GrossNotional
+			res.UnrealizedProfit += exposure.UnrealizedProfit
+		}
+		sort.Slice(res.Exposure, func(i, j int) bool {
+			return res.Exposure[i].GrossNotional > res.Exposure[j].GrossNotional
+		})
+		return res, nil
+	})
+}
+
+func listFuturesOpenOrders(symbol string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		orders, err := account.ListFuturesOpenOrders(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return orders, nil
+	})
+}
+
+func createFuturesOrder(r *FuturesOrderRequest) error {
+	err := r.Validate()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		order, err := account.CreateFuturesOrder(r)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return order.OrderID, nil
+	})
+}
+
+func getFuturesOrder(symbol string, orderID int64) error {
+	if symbol == "" || orderID == 0 {
+		return errors.New("symbol and order-id required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		order, err := account.GetFuturesOrder(symbol, orderID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return order, nil
+	})
+}
+
+func cancelFuturesOrder(symbol string, orderID int64) error {
+	if symbol == "" || orderID == 0 {
+		return errors.New("symbol and order-id required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		err := account.CancelFuturesOrder(symbol, orderID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return orderID, nil
+	})
+}
+
+func cancelFuturesOrders(symbol string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		canceledOrders, err := account.CancelFuturesOpenOrders(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return canceledOrders, nil
+	})
+}
+
+// setFuturesLeverage set leverage of symbol on each account
+func setFuturesLeverage(symbol string, leverage int) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	if leverage < 1 || leverage > 125 {
+		return errors.Errorf("invalid leverage: %d", leverage)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		res, err := account.SetFuturesLeverage(symbol, leverage)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return res, nil
+	})
+}
+
+// setFuturesMarginType switch margin type of symbol if marginType is set,
+// then add or reduce isolated margin of the position by amount if set
+func setFuturesMarginType(symbol, marginType, add, reduce string) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	marginType = strings.ToUpper(marginType)
+	if marginType != "" && marginType != "ISOLATED" && marginType != "CROSSED" {
+		return errors.Errorf("invalid margin type: %s", marginType)
+	}
+	if add != "" && reduce != "" {
+		return errors.New("add and reduce can not be set together")
+	}
+	if marginType == "" && add == "" && reduce == "" {
+		return errors.New("type, add or reduce required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		if marginType != "" {
+			err := account.SetFuturesMarginType(symbol, marginType)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if add != "" || reduce != "" {
+			err := account.AdjustPositionMargin(symbol, add+reduce, reduce != "")
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		positions, err := account.ListFuturesPositions(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return positions, nil
+	})
+}
+
+// FundingHistory define funding payments of an account with totals by symbol
+// and by asset, positive amounts are received and negative are paid
+type FundingHistory struct {
+	Payments []*FuturesIncome   `json:"payments"`
+	Symbols  map[string]float64 `json:"symbols"`
+	Totals   map[string]float64 `json:"totals"`
+}
+
+// futuresIncomeSpan is the max time range of a request of futures income
+const futuresIncomeSpan = 90 * 24 * time.Hour
+
+// listFundingHistory list funding payments of symbol, all symbols if empty,
+// totals are of the payments listed
+func listFundingHistory(symbol string, page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var payments []*FuturesIncome
+		err := page.Fetch(futuresIncomeSpan, func(startTime, endTime int64) error {
+			res, err := account.ListFuturesIncome(symbol, "FUNDING_FEE", startTime, endTime)
+			payments = append(payments, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		payments = page.Apply(payments).([]*FuturesIncome)
+		history := &FundingHistory{
+			Payments: payments,
+			Symbols:  make(map[string]float64),
+			Totals:   make(map[string]float64),
+		}
+		assets := make(map[string]string)
+		for _, p := range payments {
+			history.Symbols[p.Symbol] += StrToFloat(p.Income)
+			history.Totals[p.Asset] += StrToFloat(p.Income)
+			assets[p.Symbol] = p.Asset
+		}
+		for symbol, total := range history.Symbols {
+			history.Symbols[symbol] = roundTotal(total, assets[symbol])
+		}
+		for asset, total := range history.Totals {
+			history.Totals[asset] = roundTotal(total, asset)
+		}
+		return history, nil
+	})
+}
+
+// FuturesIncomeReport define income of an account with totals by type and
+// asset, net is the sum of all types except transfers
+type FuturesIncomeReport struct {
+	Incomes []*FuturesIncome              `json:"incomes"`
+	Totals  map[string]map[string]float64 `json:"totals"`
+	Net     map[string]float64            `json:"net"`
+}
+
+// listFuturesIncome list income of types: REALIZED_PNL, COMMISSION,
+// FUNDING_FEE ..., all types if empty. Totals are of the income listed.
+func listFuturesIncome(symbol string, types []string, page *Page) error {
+	if len(types) == 0 {
+		types = []string{""}
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		report := &FuturesIncomeReport{
+			Totals: make(map[string]map[string]float64),
+			Net:    make(map[string]float64),
+		}
+		for _, typ := range types {
+			err := page.Fetch(futuresIncomeSpan, func(startTime, endTime int64) error {
+				incomes, err := account.ListFuturesIncome(symbol, typ, startTime, endTime)
+				report.Incomes = append(report.Incomes, incomes...)
+				return errors.Trace(err)
+			})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		sort.SliceStable(report.Incomes, func(i, j int) bool {
+			return report.Incomes[i].Time < report.Incomes[j].Time
+		})
+		report.Incomes = page.Apply(report.Incomes).([]*FuturesIncome)
+		for _, income := range report.Incomes {
+			if report.Totals[income.IncomeType] == nil {
+				report.Totals[income.IncomeType] = make(map[string]float64)
+			}
+			amount := StrToFloat(income.Income)
+			report.Totals[income.IncomeType][income.Asset] += amount
+			if income.IncomeType != "TRANSFER" {
+				report.Net[income.Asset] += amount
+			}
+		}
+		for _, totals := range report.Totals {
+			for asset, total := range totals {
+				totals[asset] = roundTotal(total, asset)
+			}
+		}
+		for asset, total := range report.Net {
+			report.Net[asset] = roundTotal(total, asset)
+		}
+		return report, nil
+	})
+}
+
+// marketStepSize return step size of MARKET_LOT_SIZE of symbol, 0 if not found
+func marketStepSize(filters []map[string]interface{}) float64 {
+	for _, filter := range filters {
+		if filter["filterType"] == "MARKET_LOT_SIZE" {
+			if s, ok := filter["stepSize"].(string); ok {
+				return StrToFloat(s)
+			}
+		}
+	}
+	return 0
+}
+
+// closeFuturesPosition flatten positions of symbol with market reduce-only
+// orders, only percent of each position is closed if less than 100
+func closeFuturesPosition(symbol string, percent float64) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	if percent <= 0 || percent > 100 {
+		return errors.Errorf("invalid percent: %v", percent)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		return account.CloseFuturesPositions(symbol, percent)
+	})
+}
+
+// CloseFuturesPositions close percent of positions of symbol, or of all
+// symbols if empty, with market reduce-only orders
+func (account *Account) CloseFuturesPositions(symbol string, percent float64) ([]int64, error) {
+	positions, err := account.ListFuturesPositions(symbol)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	steps := make(map[string]float64)
+	var orderIDs []int64
+	for _, p := range positions {
+		amount := StrToFloat(p.PositionAmt)
+		side := "SELL"
+		if amount < 0 {
+			side = "BUY"
+		}
+		quantity := strings.TrimPrefix(p.PositionAmt, "-")
+		if percent < 100 {
+			step, ok := steps[p.Symbol]
+			if !ok {
+				filters, err := account.GetFuturesSymbolFilters(p.Symbol)
+				if err != nil {
+					return orderIDs, errors.Trace(err)
+				}
+				step = marketStepSize(filters)
+				steps[p.Symbol] = step
+			}
+			// round down not to flip the position
+			quantity = roundToTick(math.Abs(amount)*percent/100, step, "BUY")
+			if StrToFloat(quantity) == 0 {
+				continue
+			}
+		}
+		r := &FuturesOrderRequest{
+			Symbol:     p.Symbol,
+			Side:       side,
+			Type:       "MARKET",
+			Quantity:   quantity,
+			ReduceOnly: true,
+		}
+		if p.PositionSide != "BOTH" {
+			r.PositionSide = p.PositionSide
+		}
+		order, err := account.CreateFuturesOrder(r)
+		if err != nil {
+			return orderIDs, errors.Trace(err)
+		}
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+	return orderIDs, nil
+}
+
+// transferFutures transfer collateral between spot and USD-M futures wallets,
+// to is the destination wallet: futures or spot
+func transferFutures(to, asset, amount string) error {
+	switch strings.ToUpper(to) {
+	case "FUTURES":
+		return transfer("SPOT", "FUTURES", asset, amount)
+	case "SPOT":
+		return transfer("FUTURES", "SPOT", asset, amount)
+	}
+	return errors.Errorf("invalid wallet: %s", to)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const (
+	guardFile = "guard.json"
+	// guardWarmup is the number of checks to learn activity before alerting on bursts
+	guardWarmup = 30
+	// guardAlpha is the smoothing factor of learned rates
+	guardAlpha = 0.05
+)
+
+// ActivityBaseline define typical activity of an account learned by the guard
+type ActivityBaseline struct {
+	Checks            int      `json:"checks"`
+	OrdersPerHour     float64  `json:"orders_per_hour"`
+	WithdrawalsPerDay float64  `json:"withdrawals_per_day"`
+	Addresses         []string `json:"addresses"`
+	LastCheck         int64    `json:"last_check"`
+}
+
+func loadBaselines() (map[string]*ActivityBaseline, error) {
+	baselines := make(map[string]*ActivityBaseline)
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, guardFile))
+	if os.IsNotExist(err) {
+		return baselines, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = json.Unmarshal(data, &baselines)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return baselines, nil
+}
+
+func saveBaselines(baselines map[string]*ActivityBaseline) error {
+	err := os.MkdirAll(dataDir, 0700)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := json.MarshalIndent(baselines, "", "    ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(ioutil.WriteFile(filepath.Join(dataDir, guardFile), data, 0600))
+}
+
+// guardAlert log alert of account and pass it to alertCmd on stdin if set
+func guardAlert(alertCmd, account, format string, args ...interface{}) {
+	msg := fmt.Sprintf("%s: %s", account, fmt.Sprintf(format, args...))
+	log.Print("ALERT: ", msg)
+	if alertCmd == "" {
+		return
+	}
+	cmd := exec.Command("sh", "-c", alertCmd)
+	cmd.Stdin = strings.NewReader(msg + "\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("alert command failed: %s %s", err, strings.TrimSpace(string(out)))
+	}
+}
+
+// activityGuard watch activity of an account and compare it with its baseline
+type activityGuard struct {
+	interval time.Duration
+	factor   float64
+	minBurst int
+	alertCmd string
+	// seenOrders is open order ids of the previous check by account
+	seenOrders map[string]map[int64]bool
+
+	mu sync.Mutex
+	// newOrders is orders not created by the CLI reported by user data
+	// streams since the previous check, by account and order id
+	newOrders map[string]map[int64]string
+}
+
+// describeOrder return a short description of an order: BNBBTC BUY 1@0.001
+func describeOrder(symbol, side, quantity, price string) string {
+	return fmt.Sprintf("%s %s %s@%s", symbol, side, quantity, price)
+}
+
+// HandleUserEvent collect new orders not created by the CLI from user data
+// streams, so orders filled at once are seen too
+func (g *activityGuard) HandleUserEvent(event interface{}) {
+	update, ok := event.(*OrderUpdate)
+	if !ok || update.ExecutionType != "NEW" || strings.HasPrefix(update.ClientOrderID, clientOrderIDPrefix) {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.newOrders[update.Account] == nil {
+		g.newOrders[update.Account] = make(map[int64]string)
+	}
+	g.newOrders[update.Account][update.OrderID] = describeOrder(update.Symbol, update.Side,
+		update.Quantity, update.Price)
+}
+
+// checkOrders alert on a burst of new orders not created by the CLI, from
+// user data streams and new open orders since the previous check
+func (g *activityGuard) checkOrders(account *Account, b *ActivityBaseline) error {
+	orders, err := account.ListOpenOrders("")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	g.mu.Lock()
+	found := g.newOrders[account.Name]
+	delete(g.newOrders, account.Name)
+	g.mu.Unlock()
+	if found == nil {
+		found = make(map[int64]string)
+	}
+	seen, ok := g.seenOrders[account.Name]
+	current := make(map[int64]bool)
+	for _, order := range orders {
+		current[order.OrderID] = true
+		if ok && !seen[order.OrderID] && !strings.HasPrefix(order.ClientOrderID, clientOrderIDPrefix) {
+			found[order.OrderID] = describeOrder(order.Symbol, string(order.Side),
+				order.OrigQuantity, order.Price)
+		}
+	}
+	g.seenOrders[account.Name] = current
+	if !ok {
+		return nil
+	}
+	var foreign []string
+	for _, order := range found {
+		foreign = append(foreign, order)
+	}
+	sort.Strings(foreign)
+	rate := float64(len(foreign)) / g.interval.Hours()
+	// at least one order per hour is considered typical
+	typical := math.Max(b.OrdersPerHour, 1)
+	if b.Checks >= guardWarmup && len(foreign) >= g.minBurst && rate > g.factor*typical {
+		guardAlert(g.alertCmd, account.Name, "burst of %d orders not created by the CLI, "+
+			"%.1f/h vs typical %.1f/h: %s", len(foreign), rate, b.OrdersPerHour, strings.Join(foreign, ", "))
+	}
+	b.OrdersPerHour += guardAlpha * (rate - b.OrdersPerHour)
+	return nil
+}
+
+// checkWithdrawals alert on withdrawals to unknown addresses or more
+// withdrawals in 24 hours than usual. Addresses of the last 90 days are
+// learned on the first check.
+func (g *activityGuard) checkWithdrawals(account *Account, b *ActivityBaseline, now time.Time) error {
+	if b.LastCheck == 0 {
+		withdrawals, err := account.ListWithdrawals(0, 0)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, w := range withdrawals {
+			if !StrContains(b.Addresses, w.Address) {
+				b.Addresses = append(b.Addresses, w.Address)
+			}
+		}
+		b.WithdrawalsPerDay = float64(len(withdrawals)) / 90
+		return nil
+	}
+	withdrawals, err := account.ListWithdrawals(MilliTime(now.Add(-24*time.Hour)), 0)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var newCount int
+	for _, w := range withdrawals {
+		applyTime, err := time.Parse("2006-01-02 15:04:05", w.ApplyTime)
+		if err != nil || MilliTime(applyTime) <= b.LastCheck {
+			continue
+		}
+		newCount++
+		if !StrContains(b.Addresses, w.Address) {
+			guardAlert(g.alertCmd, account.Name, "withdrawal of %s %s to unknown address %s on %s",
+				w.Amount, w.Coin, w.Address, w.Network)
+			b.Addresses = append(b.Addresses, w.Address)
+		}
+	}
+	daily := float64(len(withdrawals))
+	if newCount > 0 && daily > g.factor*b.WithdrawalsPerDay+1 {
+		guardAlert(g.alertCmd, account.Name, "%d withdrawals in 24h vs typical %.1f/day",
+			len(withdrawals), b.WithdrawalsPerDay)
+	}
+	alpha := guardAlpha * g.interval.Hours() / 24
+	b.WithdrawalsPerDay += math.Min(alpha, 1) * (daily - b.WithdrawalsPerDay)
+	return nil
+}
+
+// runGuard learn typical order rate and withdrawals of accounts and alert on
+// anomalies every interval, as an early warning of compromised keys
+func runGuard(interval time.Duration, factor float64, minBurst int, alertCmd string) error {
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	baselines, err := loadBaselines()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	g := &activityGuard{
+		interval:   interval,
+		factor:     factor,
+		minBurst:   minBurst,
+		alertCmd:   alertCmd,
+		seenOrders: make(map[string]map[int64]bool),
+		newOrders:  make(map[string]map[int64]string),
+	}
+	accounts := findAccounts(name)
+	listenKeys, err := startUserStreams(accounts)
+	if err != nil {
+		log.Print("failed to start user streams, only open orders are checked: ", err)
+	} else {
+		for key, account := range accounts {
+			go watchUserStream(account, listenKeys[key], g.HandleUserEvent)
+		}
+	}
+	log.Printf("activity guard started, interval: %s", interval)
+	for {
+		now := time.Now()
+		for _, account := range accounts {
+			b, ok := baselines[account.Name]
+			if !ok {
+				b = new(ActivityBaseline)
+				baselines[account.Name] = b
+			}
+			err := g.checkOrders(account, b)
+			if err != nil {
+				log.Printf("%s: failed to check orders: %s", account.Name, err)
+				continue
+			}
+			err = g.checkWithdrawals(account, b, now)
+			if err != nil {
+				log.Printf("%s: failed to check withdrawals: %s", account.Name, err)
+				continue
+			}
+			b.Checks++
+			b.LastCheck = MilliTime(now)
+		}
+		err = saveBaselines(baselines)
+		if err != nil {
+			log.Print("failed to save activity baselines: ", err)
+		}
+		time.Sleep(interval)
+	}
+}
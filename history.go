@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const historyFile = "history.jsonl"
+
+var (
+	// resultSummary is a short summary of the last printed result
+	resultSummary string
+	// skipHistory is set by commands not to be recorded
+	skipHistory bool
+)
+
+// HistoryEntry define a command run by the CLI
+type HistoryEntry struct {
+	ID      int      `json:"id,omitempty"`
+	Time    int64    `json:"time"`
+	Args    []string `json:"args"`
+	Error   string   `json:"error,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+}
+
+// summarize return output with whitespace collapsed, truncated
+func summarize(out []byte) string {
+	const maxLen = 200
+	s := strings.Join(strings.Fields(string(out)), " ")
+	if runes := []rune(s); len(runes) > maxLen {
+		s = string(runes[:maxLen]) + "..."
+	}
+	return s
+}
+
+// recordHistory save command args with its result summary
+func recordHistory(args []string, err error) {
+	if len(args) == 0 || skipHistory {
+		return
+	}
+	entry := &HistoryEntry{
+		Time:    MilliTime(time.Now()),
+		Args:    args,
+		Summary: resultSummary,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	err = appendRecord(historyFile, entry)
+	if err != nil {
+		log.Print("failed to record history: ", err)
+	}
+}
+
+// loadHistory load history entries, ids are line numbers starting from 1
+func loadHistory() ([]*HistoryEntry, error) {
+	var entries []*HistoryEntry
+	err := readRecords(historyFile, func(data []byte) error {
+		entry := new(HistoryEntry)
+		err := json.Unmarshal(data, entry)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		entry.ID = len(entries) + 1
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return entries, nil
+}
+
+// searchHistory list latest limit entries containing all keywords
+func searchHistory(keywords []string, limit int) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var res []*HistoryEntry
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(res) < limit); i-- {
+		line := strings.ToLower(strings.Join(entries[i].Args, " "))
+		matched := true
+		for _, keyword := range keywords {
+			if !strings.Contains(line, strings.ToLower(keyword)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			res = append([]*HistoryEntry{entries[i]}, res...)
+		}
+	}
+	return print(res)
+}
+
+// withoutYes return args without the flag skipping confirmation, so a
+// rerun withdrawal is confirmed again
+func withoutYes(args []string) []string {
+	var res []string
+	for _, arg := range args {
+		flag := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if strings.HasPrefix(arg, "-") && (flag == "y" || flag == "yes") {
+			continue
+		}
+		res = append(res, arg)
+	}
+	return res
+}
+
+// rerunHistory run command of history entry id again after confirmation,
+// skipped if yes is set. Withdrawals are always confirmed again.
+func rerunHistory(id int, yes bool) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if id <= 0 || id > len(entries) {
+		return errors.NotFoundf("history %d", id)
+	}
+	args := entries[id-1].Args
+	if StrContains(args, "withdraw") {
+		args = withoutYes(args)
+	}
+	if !yes {
+		ok, err := confirm(T("confirm_rerun", strings.Join(args, " ")))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !ok {
+			return errors.New(T("aborted"))
+		}
+	}
+	log.Printf("running: %s", strings.Join(args, " "))
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return errors.Trace(cmd.Run())
+}
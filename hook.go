@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// TradeCheck define order details sent to the pre-trade hook
+type TradeCheck struct {
+	Account  string `json:"account"`
+	Market   string `json:"market"`
+	Symbol   string `json:"symbol"`
+	Side     string `json:"side"`
+	Type     string `json:"type"`
+	Quantity string `json:"quantity"`
+	Price    string `json:"price,omitempty"`
+}
+
+// checkTrade call the pre-trade hook with order details, an error is
+// returned if the hook vetoes the trade. A hook is either an HTTP endpoint
+// receiving the details as JSON POST body and vetoing with a non-2xx
+// status, or a command receiving the details as JSON on stdin and vetoing
+// with a non-zero exit code.
+func checkTrade(check *TradeCheck) error {
+	if preTradeHook == "" {
+		return nil
+	}
+	data, err := json.Marshal(check)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if strings.HasPrefix(preTradeHook, "http://") || strings.HasPrefix(preTradeHook, "https://") {
+		client := &http.Client{Timeout: 10 * time.Second}
+		res, err := client.Post(preTradeHook, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return errors.Annotate(err, "pre-trade hook failed")
+		}
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			reason, _ := ioutil.ReadAll(res.Body)
+			return errors.Errorf("trade vetoed by pre-trade hook: %s %s",
+				res.Status, strings.TrimSpace(string(reason)))
+		}
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", preTradeHook)
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Errorf("trade vetoed by pre-trade hook: %s %s",
+			err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// FillNotice define a filled order sent to the fill hook, price is the
+// average fill price
+type FillNotice struct {
+	Account  string `json:"account"`
+	Symbol   string `json:"symbol"`
+	Side     string `json:"side"`
+	Type     string `json:"type"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	OrderID  int64  `json:"order_id"`
+	Time     int64  `json:"time"`
+}
+
+// newFillNotice create a fill notice of a FILLED order update
+func newFillNotice(update *OrderUpdate) *FillNotice {
+	price := update.LastPrice
+	filled, quote := StrToFloat(update.FilledQuantity), StrToFloat(update.FilledQuote)
+	if filled > 0 && quote > 0 {
+		price = strconv.FormatFloat(quote/filled, 'f', -1, 64)
+	}
+	return &FillNotice{
+		Account:  update.Account,
+		Symbol:   update.Symbol,
+		Side:     update.Side,
+		Type:     update.Type,
+		Price:    price,
+		Quantity: update.FilledQuantity,
+		OrderID:  update.OrderID,
+		Time:     update.Time,
+	}
+}
+
+// notifyFill send fill to hook, either an HTTP endpoint receiving it as JSON
+// POST body or a command receiving it as JSON on stdin. Failures are logged.
+func notifyFill(hook string, fill *FillNotice) {
+	data, err := json.Marshal(fill)
+	if err != nil {
+		log.Print("failed to encode fill notice: ", err)
+		return
+	}
+	if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+		client := &http.Client{Timeout: 10 * time.Second}
+		res, err := client.Post(hook, "application/json", bytes.NewReader(data))
+		if err != nil {
+			log.Print("fill hook failed: ", err)
+			return
+		}
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			log.Print("fill hook failed: ", res.Status)
+		}
+		return
+	}
+	cmd := exec.Command("sh", "-c", hook)
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("fill hook failed: %s %s", err, strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// supported languages
+const (
+	langEN   = "en"
+	langZhCN = "zh-CN"
+)
+
+// messages define translations of user facing messages by language,
+// messages missing in a language fall back to English
+var messages = map[string]map[string]string{
+	langEN: {
+		"error":                "error",
+		"explanation":          "explanation",
+		"insecure_permissions": "permissions %04o for '%s' are too open, run fix-permissions or chmod 600 it",
+		"confirm_withdraw":     "withdraw %s %s on network %q to %s from account %s?",
+		"confirm_rerun":        "run again: %s?",
+		"confirm_answers":      "[yes/no]",
+		"yes":                  "yes",
+		"aborted":              "aborted",
+		"passphrase":           "Passphrase of %s",
+		"new_passphrase":       "New passphrase",
+		"repeat_passphrase":    "Repeat passphrase",
+
+		"api_error_-1003": "too many requests, slow down or wait before retrying",
+		"api_error_-1013": "order rejected by symbol filters, check price tick size, lot size and min notional",
+		"api_error_-1021": "request timestamp is outside recvWindow, sync the system clock",
+		"api_error_-1022": "invalid signature, check the secret key",
+		"api_error_-1100": "illegal characters found in a parameter",
+		"api_error_-1121": "invalid symbol",
+		"api_error_-2010": "order rejected, usually due to insufficient balance",
+		"api_error_-2011": "cancel rejected, the order may be already filled or canceled",
+		"api_error_-2013": "order does not exist",
+		"api_error_-2014": "invalid API key format",
+		"api_error_-2015": "invalid API key, IP or permissions for action",
+	},
+	langZhCN: {
+		"error":                "错误",
+		"explanation":          "说明",
+		"insecure_permissions": "'%[2]s' 的权限 %04[1]o 过于开放，请运行 fix-permissions 或 chmod 600",
+		"confirm_withdraw":     "确认从账户 %[5]s 提现 %[1]s %[2]s 到 %[4]s（网络 %[3]q）？",
+		"confirm_rerun":        "确认再次运行：%s？",
+		"confirm_answers":      "[是/否]",
+		"yes":                  "是",
+		"aborted":              "已取消",
+		"passphrase":           "%s 的密码",
+		"new_passphrase":       "新密码",
+		"repeat_passphrase":    "再次输入密码",
+
+		"column_account":    "账户",
+		"column_address":    "地址",
+		"column_amount":     "数量",
+		"column_asset":      "资产",
+		"column_coin":       "币种",
+		"column_createTime": "创建时间",
+		"column_error":      "错误",
+		"column_fee":        "手续费",
+		"column_free":       "可用",
+		"column_id":         "编号",
+		"column_key":        "项目",
+		"column_locked":     "冻结",
+		"column_name":       "名称",
+		"column_network":    "网络",
+		"column_orderId":    "订单号",
+		"column_order_id":   "订单号",
+		"column_price":      "价格",
+		"column_quantity":   "数量",
+		"column_side":       "方向",
+		"column_status":     "状态",
+		"column_symbol":     "交易对",
+		"column_time":       "时间",
+		"column_total":      "合计",
+		"column_type":       "类型",
+		"column_updateTime": "更新时间",
+		"column_value":      "值",
+		"column_volume":     "成交量",
+
+		"api_error_-1003": "请求过于频繁，请降低频率或稍后重试",
+		"api_error_-1013": "订单不满足交易对规则，请检查价格精度、数量精度和最小成交额",
+		"api_error_-1021": "请求时间戳超出 recvWindow，请同步系统时间",
+		"api_error_-1022": "签名无效，请检查 secret key",
+		"api_error_-1100": "参数中包含非法字符",
+		"api_error_-1121": "无效的交易对",
+		"api_error_-2010": "下单被拒绝，通常是余额不足",
+		"api_error_-2011": "撤单被拒绝，订单可能已成交或已撤销",
+		"api_error_-2013": "订单不存在",
+		"api_error_-2014": "API key 格式无效",
+		"api_error_-2015": "API key、IP 或操作权限无效",
+	},
+}
+
+// columnHeader return the header of a table column, translated if the
+// language has one or the column name in upper case
+func columnHeader(column string) string {
+	if header, ok := messages[detectLang(lang)]["column_"+column]; ok {
+		return header
+	}
+	return strings.ToUpper(column)
+}
+
+// detectLang return language of --lang, or LANG environment if not set
+func detectLang(lang string) string {
+	if lang == "" {
+		lang = os.Getenv("LANG")
+	}
+	lang = strings.Replace(strings.SplitN(lang, ".", 2)[0], "_", "-", 1)
+	if strings.HasPrefix(strings.ToLower(lang), "zh") {
+		return langZhCN
+	}
+	return langEN
+}
+
+// T translate message of key into current language and format it with args
+func T(key string, args ...interface{}) string {
+	msg, ok := messages[detectLang(lang)][key]
+	if !ok {
+		msg, ok = messages[langEN][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// explainError return localized explanation of err, empty if unknown
+func explainError(err error) string {
+	apiErr, ok := errors.Cause(err).(*binance.APIError)
+	if !ok {
+		return ""
+	}
+	key := fmt.Sprintf("api_error_%d", apiErr.Code)
+	if _, ok := messages[langEN][key]; !ok {
+		return ""
+	}
+	return T(key)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// Indicator define a technical indicator with its periods, e.g. sma:20 or macd:12:26:9
+type Indicator struct {
+	Name    string
+	Periods []int
+}
+
+// defaultPeriods of indicators when periods are not given
+var defaultPeriods = map[string][]int{
+	"sma":  {20},
+	"ema":  {20},
+	"rsi":  {14},
+	"macd": {12, 26, 9},
+	"atr":  {14},
+}
+
+// ParseIndicators parse indicators like ["sma:20", "rsi", "macd:12:26:9"]
+func ParseIndicators(items []string) ([]*Indicator, error) {
+	var indicators []*Indicator
+	for _, item := range items {
+		parts := strings.Split(strings.ToLower(item), ":")
+		periods, ok := defaultPeriods[parts[0]]
+		if !ok {
+			return nil, errors.Errorf("unknown indicator: %s", parts[0])
+		}
+		indicator := &Indicator{Name: parts[0], Periods: append([]int{}, periods...)}
+		if len(parts) > 1 {
+			if len(parts)-1 != len(periods) {
+				return nil, errors.Errorf("indicator %s requires %d periods", parts[0], len(periods))
+			}
+			for i, p := range parts[1:] {
+				n, err := strconv.Atoi(p)
+				if err != nil || n <= 0 {
+					return nil, errors.Errorf("invalid period of %s: %s", parts[0], p)
+				}
+				indicator.Periods[i] = n
+			}
+		}
+		indicators = append(indicators, indicator)
+	}
+	return indicators, nil
+}
+
+// Compute compute indicator columns over klines, values are NaN during warmup
+func (indicator *Indicator) Compute(klines []*binance.Kline) map[string][]float64 {
+	closes := make([]float64, len(klines))
+	for i, k := range klines {
+		closes[i] = StrToFloat(k.Close)
+	}
+	p := indicator.Periods
+	name := fmt.Sprintf("%s_%d", indicator.Name, p[0])
+	switch indicator.Name {
+	case "sma":
+		return map[string][]float64{name: sma(closes, p[0])}
+	case "ema":
+		return map[string][]float64{name: ema(closes, p[0])}
+	case "rsi":
+		return map[string][]float64{name: rsi(closes, p[0])}
+	case "atr":
+		return map[string][]float64{name: atr(klines, p[0])}
+	case "macd":
+		line, signal, hist := macd(closes, p[0], p[1], p[2])
+		return map[string][]float64{"macd": line, "macd_signal": signal, "macd_hist": hist}
+	}
+	return nil
+}
+
+func nans(n int) []float64 {
+	res := make([]float64, n)
+	for i := range res {
+		res[i] = math.NaN()
+	}
+	return res
+}
+
+func sma(values []float64, n int) []float64 {
+	res := nans(len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			res[i] = sum / float64(n)
+		}
+	}
+	return res
+}
+
+// ema is seeded with the SMA of the first n values, leading NaN values are skipped
+func ema(values []float64, n int) []float64 {
+	res := nans(len(values))
+	k := 2 / float64(n+1)
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < n {
+		return res
+	}
+	var sum float64
+	for _, v := range values[start : start+n] {
+		sum += v
+	}
+	prev := sum / float64(n)
+	res[start+n-1] = prev
+	for i := start + n; i < len(values); i++ {
+		prev = values[i]*k + prev*(1-k)
+		res[i] = prev
+	}
+	return res
+}
+
+// wilder smooth values with Wilder's moving average, seeded with the SMA of values[1:n+1]
+func wilder(values []float64, n int) []float64 {
+	res := nans(len(values))
+	if len(values) <= n {
+		return res
+	}
+	var sum float64
+	for _, v := range values[1 : n+1] {
+		sum += v
+	}
+	prev := sum / float64(n)
+	res[n] = prev
+	for i := n + 1; i < len(values); i++ {
+		prev = (prev*float64(n-1) + values[i]) / float64(n)
+		res[i] = prev
+	}
+	return res
+}
+
+func rsi(closes []float64, n int) []float64 {
+	gains := make([]float64, len(closes))
+	losses := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		if change > 0 {
+			gains[i] = change
+		} else {
+			losses[i] = -change
+		}
+	}
+	avgGains, avgLosses := wilder(gains, n), wilder(losses, n)
+	res := nans(len(closes))
+	for i := range closes {
+		if math.IsNaN(avgGains[i]) {
+			continue
+		}
+		if avgLosses[i] == 0 {
+			res[i] = 100
+			continue
+		}
+		res[i] = 100 - 100/(1+avgGains[i]/avgLosses[i])
+	}
+	return res
+}
+
+func atr(klines []*binance.Kline, n int) []float64 {
+	trs := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		high, low := StrToFloat(klines[i].High), StrToFloat(klines[i].Low)
+		prevClose := StrToFloat(klines[i-1].Close)
+		trs[i] = math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+	}
+	return wilder(trs, n)
+}
+
+func macd(closes []float64, fast, slow, signal int) (line, signalLine, hist []float64) {
+	fastEMA, slowEMA := ema(closes, fast), ema(closes, slow)
+	line = nans(len(closes))
+	for i := range closes {
+		line[i] = fastEMA[i] - slowEMA[i]
+	}
+	signalLine = ema(line, signal)
+	hist = nans(len(closes))
+	for i := range closes {
+		hist[i] = line[i] - signalLine[i]
+	}
+	return line, signalLine, hist
+}
+
+// KlineRow define a kline with indicator values
+type KlineRow struct {
+	*binance.Kline
+	Indicators map[string]float64 `json:"indicators,omitempty"`
+}
+
+// klineRows attach indicator values to klines, values during warmup are omitted
+func klineRows(klines []*binance.Kline, indicators []*Indicator) []*KlineRow {
+	rows := make([]*KlineRow, len(klines))
+	for i, k := range klines {
+		rows[i] = &KlineRow{Kline: k}
+	}
+	for _, indicator := range indicators {
+		for column, values := range indicator.Compute(klines) {
+			for i, v := range values {
+				if math.IsNaN(v) {
+					continue
+				}
+				if rows[i].Indicators == nil {
+					rows[i].Indicators = make(map[string]float64)
+				}
+				rows[i].Indicators[column] = v
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+const (
+	// passphraseEnv is the environment variable of the passphrase of an
+	// encrypted keyfile, prompted if not set
+	passphraseEnv = "BINANCE_KEYFILE_PASSPHRASE"
+
+	keyfileEncryption = "aes-256-gcm"
+	keyfileKDF        = "pbkdf2-sha256"
+	keyfileIterations = 600000
+)
+
+// EncryptedKeys define an encrypted keyfile, keys are encrypted by a key
+// derived from a passphrase
+type EncryptedKeys struct {
+	Encryption string `json:"encryption"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// keyPassphrase is the passphrase of the encrypted keyfile once read, so
+// commands of the shell don't prompt again
+var keyPassphrase string
+
+// derivedKey cache the key derived from keyPassphrase and salt
+var derivedKey struct {
+	salt []byte
+	key  []byte
+}
+
+// pbkdf2 derive a key of length from password and salt with HMAC-SHA256, RFC 8018
+func pbkdf2(password, salt []byte, iterations, length int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < length; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:length]
+}
+
+// keyfileCipher return the AES-GCM cipher of passphrase and salt
+func keyfileCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	var key []byte
+	if passphrase == keyPassphrase && bytes.Equal(salt, derivedKey.salt) {
+		key = derivedKey.key
+	} else {
+		key = pbkdf2([]byte(passphrase), salt, iterations, 32)
+		if passphrase == keyPassphrase {
+			derivedKey.salt, derivedKey.key = salt, key
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.Trace(err)
+}
+
+// encryptKeys encrypt keyfile data with passphrase
+func encryptKeys(data []byte, passphrase string) (*EncryptedKeys, error) {
+	keys := &EncryptedKeys{
+		Encryption: keyfileEncryption,
+		KDF:        keyfileKDF,
+		Iterations: keyfileIterations,
+		Salt:       make([]byte, 16),
+		Nonce:      make([]byte, 12),
+	}
+	_, err := rand.Read(keys.Salt)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	_, err = rand.Read(keys.Nonce)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	aead, err := keyfileCipher(passphrase, keys.Salt, keys.Iterations)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	keys.Ciphertext = aead.Seal(nil, keys.Nonce, data, nil)
+	return keys, nil
+}
+
+// Decrypt return keyfile data decrypted with passphrase
+func (keys *EncryptedKeys) Decrypt(passphrase string) ([]byte, error) {
+	if keys.Encryption != keyfileEncryption || keys.KDF != keyfileKDF {
+		return nil, errors.NotSupportedf("keyfile encryption %s with %s", keys.Encryption, keys.KDF)
+	}
+	aead, err := keyfileCipher(passphrase, keys.Salt, keys.Iterations)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err := aead.Open(nil, keys.Nonce, keys.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted keyfile")
+	}
+	return data, nil
+}
+
+// parseEncryptedKeys return the encrypted keyfile of data, nil if data is
+// a plaintext keyfile: a JSON array of keys
+func parseEncryptedKeys(data []byte) (*EncryptedKeys, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, nil
+	}
+	keys := new(EncryptedKeys)
+	err := json.Unmarshal(data, keys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return keys, nil
+}
+
+// readPassphrase return the passphrase from BINANCE_KEYFILE_PASSPHRASE, or
+// prompt on the terminal without echo
+func readPassphrase(prompt string) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !stdinTerminal() {
+		return "", errors.Errorf("passphrase required: set %s", passphraseEnv)
+	}
+	defer pauseProgress()()
+	state, err := stty("-g")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	_, err = stty("-echo")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer stty(state)
+	fmt.Fprint(os.Stderr, prompt+": ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// decryptKeyfile return keyfile data decrypted if it is encrypted, the
+// passphrase is read once and reused. The passphrase is only read if
+// prompt is set, nil is returned if it is not known yet.
+func decryptKeyfile(path string, data []byte, prompt bool) ([]byte, error) {
+	keys, err := parseEncryptedKeys(data)
+	if err != nil || keys == nil {
+		return data, errors.Trace(err)
+	}
+	if keyPassphrase == "" {
+		keyPassphrase = os.Getenv(passphraseEnv)
+	}
+	if keyPassphrase == "" {
+		if !prompt {
+			return nil, nil
+		}
+		keyPassphrase, err = readPassphrase(T("passphrase", path))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	data, err = keys.Decrypt(keyPassphrase)
+	if err != nil {
+		keyPassphrase = ""
+		return nil, errors.Trace(err)
+	}
+	return data, nil
+}
+
+// writeSecretFile replace file at path with data readable by the current user only
+func writeSecretFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, path))
+}
+
+// encryptKeyfile encrypt the plaintext keyfile at path into output, or
+// decrypt an encrypted one if decrypt is set
+func encryptKeyfile(path, output string, decrypt bool) error {
+	if output == "" {
+		output = path
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	encrypted, err := parseEncryptedKeys(data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if decrypt {
+		if encrypted == nil {
+			return errors.Errorf("%s is not encrypted", path)
+		}
+		data, err = decryptKeyfile(path, data, true)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = writeSecretFile(output, data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		log.Printf("decrypted %s into %s", path, output)
+		return nil
+	}
+	if encrypted != nil {
+		return errors.Errorf("%s is already encrypted", path)
+	}
+	var keys []AccountKey
+	err = json.Unmarshal(data, &keys)
+	if err != nil {
+		return errors.Annotatef(err, "invalid keyfile %s", path)
+	}
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		passphrase, err = readPassphrase(T("new_passphrase"))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		again, err := readPassphrase(T("repeat_passphrase"))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if passphrase != again {
+			return errors.New("passphrases do not match")
+		}
+	}
+	if passphrase == "" {
+		return errors.New("passphrase required")
+	}
+	keysFile, err := encryptKeys(data, passphrase)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	out, err := json.MarshalIndent(keysFile, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = writeSecretFile(output, append(out, '\n'))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("encrypted %s into %s", path, output)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+
+	"github.com/juju/errors"
+)
+
+// backends of secret keys
+const (
+	keyBackendFile    = "file"
+	keyBackendKeyring = "keyring"
+)
+
+// keyringService is the service name of secrets stored in the OS keyring,
+// secrets are stored by account name
+const keyringService = "binance-cli"
+
+// keyBackend is where secret keys are read from: file for the keyfile,
+// keyring for the OS keyring
+var keyBackend string
+
+// readSecrets fill secret keys of keys from the key backend
+func readSecrets(keys []AccountKey) error {
+	switch keyBackend {
+	case keyBackendFile:
+		for _, key := range keys {
+			if key.SecretKey == "" {
+				return errors.Errorf("secret key of %s is not in the keyfile, secret keys are in the keyring, use --key-backend %s",
+					key.Name, keyBackendKeyring)
+			}
+		}
+		return nil
+	case keyBackendKeyring:
+	default:
+		return errors.Errorf("invalid key backend: %s", keyBackend)
+	}
+	for i := range keys {
+		secret, err := keyringGet(keys[i].Name)
+		if err != nil {
+			return errors.Annotatef(err, "secret key of %s not found in keyring, run store-secrets", keys[i].Name)
+		}
+		keys[i].SecretKey = secret
+	}
+	return nil
+}
+
+// storeSecrets move secret keys of the keyfile into the OS keyring, the
+// keyfile keeps names and api keys and is encrypted again if it was
+func storeSecrets() error {
+	keys, err := loadKeys(keyfile, true)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	stored := 0
+	for i := range keys {
+		if keys[i].SecretKey == "" {
+			continue
+		}
+		err = keyringSet(keys[i].Name, keys[i].SecretKey)
+		if err != nil {
+			return errors.Annotatef(err, "failed to store secret key of %s", keys[i].Name)
+		}
+		keys[i].SecretKey = ""
+		stored++
+	}
+	data, err := json.MarshalIndent(keys, "", "    ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data = append(data, '\n')
+	raw, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if encrypted, _ := parseEncryptedKeys(raw); encrypted != nil {
+		encrypted, err = encryptKeys(data, keyPassphrase)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		data, err = json.MarshalIndent(encrypted, "", "  ")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		data = append(data, '\n')
+	}
+	err = writeSecretFile(keyfile, data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("stored secret keys of %d accounts in the keyring, use --key-backend %s", stored, keyBackendKeyring)
+	return nil
+}
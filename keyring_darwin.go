@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// keyringGet read the secret of account from the macOS Keychain
+func keyringGet(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password",
+		"-s", keyringService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// securityQuote quote s as an argument of an interactive security command
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// keyringSet save the secret of account into the macOS Keychain, replacing
+// an existing one. The command is passed to security -i on stdin, so the
+// secret is not visible in arguments of the process.
+func keyringSet(account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(strings.Join([]string{"add-generic-password", "-U",
+		"-s", securityQuote(keyringService), "-a", securityQuote(account),
+		"-l", securityQuote(keyringService + " " + account), "-w", securityQuote(secret)}, " ") + "\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Annotate(err, strings.TrimSpace(string(out)))
+	}
+	// security -i does not fail on errors of its commands
+	stored, err := keyringGet(account)
+	if err != nil || stored != secret {
+		return errors.Errorf("failed to store secret: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// keyringGet read the secret of account from the Secret Service by
+// secret-tool of libsecret
+func keyringGet(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup",
+		"service", keyringService, "account", account).Output()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if len(out) == 0 {
+		return "", errors.NotFoundf("secret of %s", account)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// keyringSet save the secret of account into the Secret Service, the
+// secret is passed on stdin
+func keyringSet(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", keyringService+" "+account,
+		"service", keyringService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	out, err := cmd.CombinedOutput()
+	if msg := strings.TrimSpace(string(out)); err != nil && msg != "" {
+		return errors.Annotate(err, msg)
+	}
+	return errors.Trace(err)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/juju/errors"
+)
+
+// generic credentials of Windows Credential Manager persisted across logons
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential is CREDENTIALW of wincred.h
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// keyringTarget return the target name of the credential of account
+func keyringTarget(account string) (*uint16, error) {
+	target, err := syscall.UTF16PtrFromString(keyringService + ":" + account)
+	return target, errors.Trace(err)
+}
+
+// keyringGet read the secret of account from Windows Credential Manager
+func keyringGet(account string) (string, error) {
+	target, err := keyringTarget(account)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var cred *credential
+	r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return "", errors.Trace(err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	blob := (*[1 << 20]byte)(unsafe.Pointer(cred.CredentialBlob))[:cred.CredentialBlobSize:cred.CredentialBlobSize]
+	return string(blob), nil
+}
+
+// keyringSet save the secret of account into Windows Credential Manager,
+// replacing an existing one
+func keyringSet(account, secret string) error {
+	if secret == "" {
+		return errors.New("empty secret")
+	}
+	target, err := keyringTarget(account)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	blob := []byte(secret)
+	cred := &credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(cred)), 0)
+	if r == 0 {
+		return errors.Trace(err)
+	}
+	return nil
+}
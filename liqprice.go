@@ -0,0 +1,199 @@
+package main
+
+import (
+	"math"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// bracketOf return the bracket of brackets containing notional, the last
+// bracket if notional is above all caps
+func bracketOf(brackets []*LeverageBracket, notional float64) *LeverageBracket {
+	for _, b := range brackets {
+		if notional >= b.NotionalFloor && notional < b.NotionalCap {
+			return b
+		}
+	}
+	return brackets[len(brackets)-1]
+}
+
+// liquidationPrice compute liquidation price of a position in one-way mode
+// with the formula of Binance: side is 1 for long and -1 for short, wallet is
+// the isolated margin or the cross wallet balance, otherMaint and otherPnl are
+// maintenance margin and unrealized pnl of other cross positions
+func liquidationPrice(side, size, entry, wallet, otherMaint, otherPnl float64, bracket *LeverageBracket) float64 {
+	price := (wallet - otherMaint + otherPnl + bracket.Cum - side*size*entry) /
+		(size*bracket.MaintMarginRatio - side*size)
+	return math.Max(price, 0)
+}
+
+// LiquidationEstimate define liquidation price of a position
+type LiquidationEstimate struct {
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"`
+	Quantity         float64 `json:"quantity"`
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price,omitempty"`
+	MarginType       string  `json:"margin_type"`
+	Margin           float64 `json:"margin"`
+	MaintMarginRatio float64 `json:"maint_margin_ratio"`
+	MaintAmount      float64 `json:"maint_amount"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	// Distance is the move in percent from the entry or mark price to liquidation
+	Distance float64 `json:"distance"`
+}
+
+// newLiquidationEstimate compute liquidation estimate of a position and its
+// distance from the mark price, or entry price if mark is 0
+func newLiquidationEstimate(symbol, marginType string, amount, entry, mark, margin, otherMaint, otherPnl float64,
+	brackets []*LeverageBracket) *LiquidationEstimate {
+	side, sideName := 1.0, "LONG"
+	if amount < 0 {
+		side, sideName = -1, "SHORT"
+	}
+	size := math.Abs(amount)
+	bracket := bracketOf(brackets, size*entry)
+	estimate := &LiquidationEstimate{
+		Symbol:           symbol,
+		Side:             sideName,
+		Quantity:         size,
+		EntryPrice:       entry,
+		MarkPrice:        mark,
+		MarginType:       marginType,
+		Margin:           margin,
+		MaintMarginRatio: bracket.MaintMarginRatio,
+		MaintAmount:      bracket.Cum,
+		LiquidationPrice: liquidationPrice(side, size, entry, margin, otherMaint, otherPnl, bracket),
+	}
+	ref := entry
+	if mark > 0 {
+		ref = mark
+	}
+	if ref > 0 {
+		estimate.Distance = math.Abs(ref-estimate.LiquidationPrice) / ref * 100
+	}
+	return estimate
+}
+
+// estimateLiquidation compute liquidation price of a hypothetical isolated
+// position, margin defaults to the initial margin of leverage
+func estimateLiquidation(symbol, side, quantity, entryPrice, margin string, leverage int) error {
+	if symbol == "" || quantity == "" || entryPrice == "" {
+		return errors.New("symbol, quantity and entry-price required")
+	}
+	amount, entry := StrToFloat(quantity), StrToFloat(entryPrice)
+	if amount <= 0 {
+		return errors.Errorf("quantity must be positive: %s", quantity)
+	}
+	if entry <= 0 {
+		return errors.Errorf("entry-price must be positive: %s", entryPrice)
+	}
+	switch strings.ToUpper(side) {
+	case "BUY", "LONG":
+	case "SELL", "SHORT":
+		amount = -amount
+	default:
+		return errors.Errorf("invalid side: %s", side)
+	}
+	wallet := StrToFloat(margin)
+	if margin == "" {
+		if leverage <= 0 {
+			return errors.New("margin or a positive leverage required")
+		}
+		wallet = math.Abs(amount) * entry / float64(leverage)
+	} else if wallet <= 0 {
+		return errors.Errorf("margin must be positive: %s", margin)
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		brackets, err := account.ListLeverageBrackets(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(brackets) == 0 {
+			return nil, errors.Errorf("no leverage brackets of %s", symbol)
+		}
+		return newLiquidationEstimate(strings.ToUpper(symbol), "isolated", amount, entry, 0, wallet, 0, 0, brackets), nil
+	})
+}
+
+// listLiquidationPrices compute liquidation prices of existing positions of
+// symbol. Cross positions use the cross wallet balance of the margin asset
+// with maintenance margin and unrealized pnl of other cross positions.
+func listLiquidationPrices(symbol string) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	symbol = strings.ToUpper(symbol)
+	return accountsDo(func(account *Account) (interface{}, error) {
+		positions, err := account.ListFuturesPositions("")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		brackets := make(map[string][]*LeverageBracket)
+		getBrackets := func(symbol string) ([]*LeverageBracket, error) {
+			if _, ok := brackets[symbol]; !ok {
+				res, err := account.ListLeverageBrackets(symbol)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				brackets[symbol] = res
+			}
+			return brackets[symbol], nil
+		}
+		// balances and margin assets are fetched once for cross positions
+		var balances []*FuturesBalance
+		var marginAssets map[string]string
+		var estimates []*LiquidationEstimate
+		for _, p := range positions {
+			if p.Symbol != symbol {
+				continue
+			}
+			symbolBrackets, err := getBrackets(p.Symbol)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			amount, entry, mark := StrToFloat(p.PositionAmt), StrToFloat(p.EntryPrice), StrToFloat(p.MarkPrice)
+			if p.MarginType == "isolated" {
+				estimates = append(estimates, newLiquidationEstimate(p.Symbol, p.MarginType, amount, entry, mark,
+					StrToFloat(p.IsolatedWallet), 0, 0, symbolBrackets))
+				continue
+			}
+			if marginAssets == nil {
+				if balances, err = account.ListFuturesBalances(); err != nil {
+					return nil, errors.Trace(err)
+				}
+				if marginAssets, err = account.ListFuturesMarginAssets(); err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+			marginAsset, ok := marginAssets[p.Symbol]
+			if !ok {
+				return nil, errors.NotFoundf("margin asset of %s", p.Symbol)
+			}
+			var wallet float64
+			for _, b := range balances {
+				if b.Asset == marginAsset {
+					wallet = StrToFloat(b.CrossWalletBalance)
+				}
+			}
+			var otherMaint, otherPnl float64
+			for _, other := range positions {
+				if other == p || other.MarginType == "isolated" {
+					continue
+				}
+				otherBrackets, err := getBrackets(other.Symbol)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				notional := math.Abs(StrToFloat(other.Notional))
+				b := bracketOf(otherBrackets, notional)
+				otherMaint += notional*b.MaintMarginRatio - b.Cum
+				otherPnl += StrToFloat(other.UnRealizedProfit)
+			}
+			estimates = append(estimates, newLiquidationEstimate(p.Symbol, p.MarginType, amount, entry, mark,
+				wallet, otherMaint, otherPnl, symbolBrackets))
+		}
+		return estimates, nil
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/adshao/go-binance"
 	"github.com/juju/errors"
@@ -16,22 +18,38 @@ var (
 	name     string
 	keyfile  string
 	debug    bool
+	dataDir  string
 	accounts map[string]*Account
 	assets   []string
+
+	lang                string
+	preTradeHook        string
+	insecurePermissions bool
+	redactOutput        bool
+	forcePolling        bool
+	pollInterval        time.Duration
 )
 
 // AccountKey define key info for account
 type AccountKey struct {
-	Name      string `json:"name"`
-	APIKey    string `json:"api_key"`
-	SecretKey string `json:"secret_key"`
+	Name      string   `json:"name"`
+	APIKey    string   `json:"api_key"`
+	SecretKey string   `json:"secret_key,omitempty"`
+	Assets    []string `json:"assets,omitempty"`
+	Symbols   []string `json:"symbols,omitempty"`
 }
 
-func loadKeys(filePath string) ([]AccountKey, error) {
+// loadKeys load keys of keyfile, the passphrase of an encrypted keyfile is
+// prompted if prompt is set, otherwise no keys are returned until it is known
+func loadKeys(filePath string, prompt bool) ([]AccountKey, error) {
 	keyBytes, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	keyBytes, err = decryptKeyfile(filePath, keyBytes, prompt)
+	if err != nil || keyBytes == nil {
+		return nil, errors.Trace(err)
+	}
 	var keys []AccountKey
 	err = json.Unmarshal(keyBytes, &keys)
 	if err != nil {
@@ -41,13 +59,18 @@ func loadKeys(filePath string) ([]AccountKey, error) {
 }
 
 func initAccounts() {
-	if keyfile == "" {
-		keyfile = "keys.json"
+	err := checkPermissions(keyfile)
+	if err != nil {
+		log.Fatal(err)
 	}
-	keys, err := loadKeys(keyfile)
+	keys, err := loadKeys(keyfile, true)
 	if err != nil {
 		log.Fatal("failed to load keys: ", err)
 	}
+	err = readSecrets(keys)
+	if err != nil {
+		log.Fatal("failed to read secret keys: ", err)
+	}
 	accounts = make(map[string]*Account)
 	for _, key := range keys {
 		client := binance.NewClient(
@@ -60,6 +83,8 @@ func initAccounts() {
 		account := new(Account)
 		account.Client = client
 		account.Name = key.Name
+		account.Assets = key.Assets
+		account.Symbols = key.Symbols
 		accounts[account.Name] = account
 	}
 }
@@ -74,33 +99,45 @@ func findAccountsImpl(name string) map[string]*Account {
 	return map[string]*Account{name: accounts[name]}
 }
 
+// runOnce run action with a single account, for public data not
+// depending on the account
 func runOnce(action func(*Account) (interface{}, error),
 	postAction ...func(map[string]interface{}) (interface{}, error)) error {
-	findAccounts = func(name string) map[string]*Account {
-		initAccounts()
-		for k, v := range accounts {
-			return map[string]*Account{k: v}
-		}
-		return nil
+	once := make(map[string]*Account)
+	for k, v := range findAccounts(name) {
+		once[k] = v
+		break
 	}
-	return accountsDo(action, postAction...)
+	return accountsRun(once, action, postAction...)
 }
 
 func accountsDo(action func(*Account) (interface{}, error),
 	postAction ...func(map[string]interface{}) (interface{}, error)) error {
-	accounts := findAccounts(name)
+	return accountsRun(findAccounts(name), action, postAction...)
+}
+
+// accountsRun run action with each of accounts and print results
+func accountsRun(accounts map[string]*Account, action func(*Account) (interface{}, error),
+	postAction ...func(map[string]interface{}) (interface{}, error)) error {
 	var ret interface{}
 	var err error
 	results := make(map[string]interface{})
-	for _, account := range accounts {
+	progress := startProgress("accounts", len(accounts))
+	for i, account := range fanOutOrder(accounts) {
+		fanOutWait(i)
 		res, err := action(account)
 		if err != nil {
 			// return errors.Trace(err)
-			results[account.Name] = fmt.Sprintf("error: %s", err)
+			results[account.Name] = fmt.Sprintf("%s: %s", T("error"), err)
+			if explanation := explainError(err); explanation != "" {
+				results[account.Name] = fmt.Sprintf("%s: %s (%s)", T("error"), err, explanation)
+			}
 		} else {
 			results[account.Name] = res
 		}
+		progress.Add(1)
 	}
+	progress.Done()
 	if len(postAction) > 0 {
 		ret, err = postAction[0](results)
 		if err != nil {
@@ -112,13 +149,104 @@ func accountsDo(action func(*Account) (interface{}, error),
 	return print(ret)
 }
 
-func print(ret interface{}) error {
-	out, err := json.MarshalIndent(ret, "", "    ")
+// timeRangeFlags define time range flags of market data commands
+var timeRangeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "start-time",
+		Usage: "start time: 2018-01-02, RFC3339 or relative like 7d, 12h",
+	},
+	cli.StringFlag{
+		Name:  "end-time",
+		Usage: "end time: 2018-01-02, RFC3339 or relative like 7d, 12h",
+	},
+}
+
+// historyFlags define time range and paging flags of history commands
+var historyFlags = append([]cli.Flag{
+	cli.IntFlag{
+		Name:  "limit",
+		Usage: "max number of records of each account, 0 for all",
+	},
+	cli.Int64Flag{
+		Name:  "from-id",
+		Usage: "only records with ids from this on",
+	},
+	cli.BoolFlag{
+		Name:  "all",
+		Usage: "fetch all pages from start time to end time, instead of a single request with defaults of the API",
+	},
+}, timeRangeFlags...)
+
+// watchFlags define flags of polling a market metric with alert thresholds
+var watchFlags = []cli.Flag{
+	cli.DurationFlag{
+		Name:  "watch",
+		Usage: "print latest value every interval: 1m, instead of history",
+	},
+	cli.Float64Flag{
+		Name:  "alert-above",
+		Usage: "alert when watched value rises above it",
+	},
+	cli.Float64Flag{
+		Name:  "alert-below",
+		Usage: "alert when watched value falls below it",
+	},
+}
+
+// refreshFlag define the flag of redrawing results of list commands in place
+var refreshFlag = cli.DurationFlag{
+	Name:  "watch",
+	Usage: "rerun every interval: 5s, and redraw output in place with changes highlighted",
+}
+
+// parseMetricWatch parse watch flags, thresholds are nil if not set
+func parseMetricWatch(c *cli.Context) *MetricWatch {
+	watch := &MetricWatch{Interval: c.Duration("watch")}
+	if c.IsSet("alert-above") {
+		above := c.Float64("alert-above")
+		watch.Above = &above
+	}
+	if c.IsSet("alert-below") {
+		below := c.Float64("alert-below")
+		watch.Below = &below
+	}
+	return watch
+}
+
+// parseTimeRange parse time range flags into milliseconds, zero if not set
+func parseTimeRange(c *cli.Context) (startTime, endTime int64, err error) {
+	startTime, err = ParseTime(c.String("start-time"))
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	endTime, err = ParseTime(c.String("end-time"))
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	return startTime, endTime, nil
+}
+
+// parsePage parse history flags
+func parsePage(c *cli.Context) (*Page, error) {
+	startTime, endTime, err := parseTimeRange(c)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
-	fmt.Println(string(out))
-	return nil
+	return &Page{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Limit:     c.Int("limit"),
+		FromID:    c.Int64("from-id"),
+		All:       c.Bool("all"),
+	}, nil
+}
+
+// logError log err with its explanation
+func logError(err error) {
+	if explanation := explainError(err); explanation != "" {
+		log.Printf("%s: %s", T("explanation"), explanation)
+	}
+	log.Print(errors.ErrorStack(err))
 }
 
 func main() {
@@ -134,6 +262,7 @@ func main() {
 		cli.StringFlag{
 			Name:        "keyfile",
 			Usage:       "file path of api keys",
+			Value:       "keys.json",
 			Destination: &keyfile,
 		},
 		cli.BoolFlag{
@@ -141,6 +270,144 @@ func main() {
 			Usage:       "show debug info",
 			Destination: &debug,
 		},
+		cli.StringFlag{
+			Name:        "data-dir",
+			EnvVar:      "BINANCE_DATA_DIR",
+			Usage:       "directory of local state",
+			Value:       filepath.Join(os.Getenv("HOME"), ".binance-cli"),
+			Destination: &dataDir,
+		},
+		cli.StringFlag{
+			Name:        "lang",
+			Usage:       "language of output: en, zh-CN, default from LANG",
+			Destination: &lang,
+		},
+		cli.StringFlag{
+			Name:        "pre-trade-hook",
+			EnvVar:      "BINANCE_PRE_TRADE_HOOK",
+			Usage:       "command or http endpoint called with order details before trading, can veto the trade",
+			Destination: &preTradeHook,
+		},
+		cli.BoolFlag{
+			Name:        "redact",
+			Usage:       "mask account names, absolute amounts and ids for sharing",
+			Destination: &redactOutput,
+		},
+		cli.BoolFlag{
+			Name:        "poll",
+			Usage:       "poll REST API instead of websocket streams",
+			Destination: &forcePolling,
+		},
+		cli.DurationFlag{
+			Name:        "poll-interval",
+			Usage:       "interval of REST polling when websocket is unavailable",
+			Value:       5 * time.Second,
+			Destination: &pollInterval,
+		},
+		cli.StringFlag{
+			Name:        "key-backend",
+			EnvVar:      "BINANCE_KEY_BACKEND",
+			Usage:       "where secret keys are read from: file for the keyfile, keyring for the OS keyring",
+			Value:       keyBackendFile,
+			Destination: &keyBackend,
+		},
+		cli.BoolFlag{
+			Name:        "insecure-permissions",
+			Usage:       "only warn when keyfile is readable by others",
+			Destination: &insecurePermissions,
+		},
+		cli.DurationFlag{
+			Name:        "price-cache-ttl",
+			Usage:       "reuse fetched prices within an invocation for this long, 0 to disable",
+			Value:       2 * time.Second,
+			Destination: &priceCacheTTL,
+		},
+		cli.StringFlag{
+			Name:        "rounding",
+			Usage:       "rounding mode of totals: half-up or half-even",
+			Value:       roundHalfUp,
+			Destination: &roundingMode,
+		},
+		cli.StringFlag{
+			Name:        "decimals",
+			Usage:       "decimal places of totals by asset: USDT=2,BTC=8,*=8, not rounded if not set",
+			Destination: &decimalsSpec,
+		},
+		cli.StringFlag{
+			Name:        "output, o",
+			Usage:       "output format: json, table, csv, yaml, ndjson or go-template='{{.Symbol}}', default to table on a terminal and json otherwise",
+			Destination: &outputFormat,
+		},
+		cli.BoolFlag{
+			Name:        "compact",
+			Usage:       "print JSON results on a single line",
+			Destination: &compactOutput,
+		},
+		cli.BoolFlag{
+			Name:        "quiet, q",
+			Usage:       "only print primary identifiers of results one per line: order ids, symbols",
+			Destination: &quietOutput,
+		},
+		cli.StringFlag{
+			Name:        "query",
+			Usage:       "JMESPath query applied to results before printing: main[?free > `0`].asset",
+			Destination: &outputQuery,
+		},
+		cli.StringSliceFlag{
+			Name:  "filter",
+			Usage: "keep items of lists in results matching field op value, op is =, !=, <, <=, >, >= or ~ for contains: 'free>0.01', can be repeated",
+			Value: &outputFilters,
+		},
+		cli.StringFlag{
+			Name:        "sort-by",
+			Usage:       "sort lists in results by field, numbers by value: priceChangePercent:desc",
+			Destination: &outputSortBy,
+		},
+		cli.StringFlag{
+			Name:        "columns",
+			Usage:       "columns of table and csv output: symbol,price, default to main columns of the command in table output",
+			Destination: &outputColumns,
+		},
+		cli.StringFlag{
+			Name:        "numbers",
+			Usage:       "format of quantities in table output: raw, grouped for 1,234.5 or short for 1.2K",
+			Value:       numbersRaw,
+			Destination: &numberFormat,
+		},
+		cli.IntFlag{
+			Name:        "table-decimals",
+			Usage:       "decimal places of quantities in table output, -1 to keep them as returned",
+			Value:       -1,
+			Destination: &numberDecimals,
+		},
+		cli.StringFlag{
+			Name:        "tz",
+			EnvVar:      "BINANCE_TZ",
+			Usage:       "time zone of times in table output: UTC, Asia/Shanghai, default to local time",
+			Destination: &timeZone,
+		},
+		cli.BoolFlag{
+			Name:        "no-color",
+			Usage:       "disable colors of table output, also disabled by NO_COLOR or when not on a terminal",
+			Destination: &noColor,
+		},
+		// deprecated alias of --output
+		cli.StringFlag{
+			Name:        "format",
+			Hidden:      true,
+			Destination: &outputFormat,
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		err := initOutput()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = initNumbers()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return initRounding()
 	}
 	app.Commands = []cli.Command{
 		{
@@ -150,88 +417,1920 @@ func main() {
 				cli.StringSliceFlag{
 					Name:   "assets",
 					EnvVar: "BINANCE_ASSETS",
-					Usage:  "list balances with asset BTC, BNB ...",
+					Usage:  "list balances with asset BTC, BNB ..., default to assets of account in keyfile",
 					Value:  &cli.StringSlice{"BTC", "BNB", "WINK", "USDT"},
 				},
+				cli.BoolFlag{
+					Name:  "all",
+					Usage: "list all non-zero balances instead of selected assets",
+				},
 				cli.BoolTFlag{
 					Name:  "total",
 					Usage: "show total balance",
 				},
+				cli.StringFlag{
+					Name:  "quote",
+					Usage: "show value of each balance including locked amount in BTC and quote asset: USDT",
+				},
+				cli.StringFlag{
+					Name:  "wallet",
+					Usage: "wallet of balances: spot, funding or all to sum both",
+					Value: "spot",
+				},
+				refreshFlag,
 			},
 			Action: func(c *cli.Context) error {
-				return listBalances(c.StringSlice("assets"), c.Bool("total"))
+				return watchRefresh("list-balances", c.Duration("watch"), func() error {
+					return listBalances(SplitItems(c.StringSlice("assets")), c.IsSet("assets"),
+						c.Bool("all"), c.Bool("total"), c.String("quote"), c.String("wallet"))
+				})
 			},
 		},
 		{
-			Name:  "list-prices",
-			Usage: "list latest price for a symbol or symbols",
+			Name:  "portfolio",
+			Usage: "value all balances of wallets in a quote asset with per-asset and per-account totals",
 			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "wallets",
+					Usage: "wallets to include: spot,funding,earn,margin,futures, default spot,funding",
+				},
 				cli.StringFlag{
-					Name:  "symbol",
-					Usage: "filter with symbol",
+					Name:  "quote",
+					Usage: "quote asset of values: USDT, BTC, EUR ...",
+					Value: "USDT",
 				},
 			},
 			Action: func(c *cli.Context) error {
-				return listPrices(c.String("symbol"))
+				return showPortfolio(SplitItems(c.StringSlice("wallets")), c.String("quote"))
 			},
 		},
 		{
-			Name:  "list-orders",
-			Usage: "list open orders",
+			Name:  "snapshot",
+			Usage: "record balances and values of wallets into the local snapshot store",
 			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "wallets",
+					Usage: "wallets to include: spot,funding,earn,margin,futures, default spot,funding",
+				},
 				cli.StringFlag{
-					Name:  "symbol",
-					Usage: "list orders with symbol",
+					Name:  "quote",
+					Usage: "quote asset of values: USDT, BTC, EUR ...",
+					Value: "USDT",
 				},
 			},
 			Action: func(c *cli.Context) error {
-				return listOpenOrders(c.String("symbol"))
+				return recordSnapshot(SplitItems(c.StringSlice("wallets")), c.String("quote"))
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "show",
+					Usage: "show holdings of the latest snapshot at a time",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "at",
+							Usage: "time of snapshot: 2018-01-02 (end of day) or RFC3339, now if not set",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return showSnapshot(c.String("at"))
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "list totals of snapshots over time",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "also list amount and value of asset: BTC",
+						},
+					}, timeRangeFlags...),
+					Action: func(c *cli.Context) error {
+						startTime, endTime, err := parseTimeRange(c)
+						if err != nil {
+							return errors.Trace(err)
+						}
+						return listSnapshots(c.String("asset"), startTime, endTime)
+					},
+				},
 			},
 		},
 		{
-			Name:  "create-order",
-			Usage: "create order",
+			Name:  "earn-balances",
+			Usage: "list Simple Earn flexible and locked positions with accrued rewards",
 			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:  "symbol",
-					Usage: "symbol name: BNBBTC",
+					Name:  "asset",
+					Usage: "filter with asset: USDT",
 				},
+			},
+			Action: func(c *cli.Context) error {
+				return listEarnBalances(c.String("asset"))
+			},
+		},
+		{
+			Name:  "list-deposits",
+			Usage: "list crypto deposit history",
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
-					Name:  "side",
-					Usage: "side type: SELL or BUY",
+					Name:  "asset",
+					Usage: "filter with asset: BTC",
+				},
+				cli.IntFlag{
+					Name:  "status",
+					Usage: "filter with status: 0 pending, 6 credited, 1 success, -1 all",
+					Value: -1,
 				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listDeposits(c.String("asset"), c.Int("status"), page)
+			},
+		},
+		{
+			Name:  "deposit-address",
+			Usage: "show deposit address and tag of an asset",
+			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:  "quantity",
-					Usage: "quantity of symbol",
+					Name:  "asset",
+					Usage: "asset name: BTC",
 				},
 				cli.StringFlag{
-					Name:  "price",
-					Usage: "price of symbol",
+					Name:  "network",
+					Usage: "network name: BSC, ETH ..., default network if not set",
 				},
 			},
 			Action: func(c *cli.Context) error {
-				return createOrder(
-					c.String("symbol"), c.String("side"),
-					c.String("quantity"), c.String("price"))
+				return getDepositAddress(c.String("asset"), c.String("network"))
 			},
 		},
 		{
-			Name:  "cancel-orders",
-			Usage: "cancel open orders",
+			Name:  "asset-detail",
+			Usage: "show withdraw fees, limits and deposit/withdraw status of assets by network",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "assets",
+					Usage: "assets: BTC,USDT",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return listAssetDetails(SplitItems(c.StringSlice("assets")))
+			},
+		},
+		{
+			Name:  "withdraw",
+			Usage: "withdraw asset from a single account to an address after confirmation",
 			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:  "symbol",
-					Usage: "cancel open orders with symbol",
+					Name:  "asset",
+					Usage: "asset name: BTC",
+				},
+				cli.StringFlag{
+					Name:  "address",
+					Usage: "withdraw address",
+				},
+				cli.StringFlag{
+					Name:  "address-tag",
+					Usage: "address tag or memo",
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "amount to withdraw",
+				},
+				cli.StringFlag{
+					Name:  "network",
+					Usage: "network name: BSC, ETH ..., default network if not set",
+				},
+				cli.StringFlag{
+					Name:  "whitelist",
+					Usage: "file of allowed addresses, one per line",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "check network, limits and balance without withdrawing",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "skip confirmation",
 				},
 			},
 			Action: func(c *cli.Context) error {
-				return cancelOrders(c.String("symbol"))
+				return withdraw(c.String("asset"), c.String("address"), c.String("address-tag"),
+					c.String("amount"), c.String("network"), c.String("whitelist"),
+					c.Bool("dry-run"), c.Bool("yes"))
+			},
+		},
+		{
+			Name:  "transfer",
+			Usage: "transfer asset between wallets: SPOT, FUNDING, MARGIN, FUTURES, COIN-FUTURES, OPTION",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "wallet to transfer from",
+					Value: "SPOT",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "wallet to transfer to",
+				},
+				cli.StringFlag{
+					Name:  "asset",
+					Usage: "asset name: USDT",
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "amount to transfer",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return transfer(c.String("from"), c.String("to"), c.String("asset"), c.String("amount"))
+			},
+		},
+		{
+			Name:  "transfer-history",
+			Usage: "list transfers between wallets",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "wallet transferred from",
+					Value: "SPOT",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "wallet transferred to",
+				},
+				cli.BoolFlag{
+					Name:  "both",
+					Usage: "also list transfers in the reverse direction",
+				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listTransfers(c.String("from"), c.String("to"), c.Bool("both"), page)
+			},
+		},
+		{
+			Name:  "fiat-history",
+			Usage: "list fiat deposits, withdrawals and card payments with status and fees",
+			Flags: append([]cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "type",
+					Usage: "history types: deposit,withdraw,buy,sell, all if not set",
+				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listFiatHistory(SplitItems(c.StringSlice("type")), page)
+			},
+		},
+		{
+			Name:  "pay-history",
+			Usage: "list Binance Pay transactions, marking transfers between configured accounts",
+			Flags: historyFlags,
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listPayTransactions(page)
+			},
+		},
+		{
+			Name:  "convert-quote",
+			Usage: "get quote of swapping assets with Binance Convert, accept it at once with --accept",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "asset to convert from: USDT",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "asset to convert to: BTC",
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "amount of asset to convert from",
+				},
+				cli.BoolFlag{
+					Name:  "to-amount",
+					Usage: "amount is of asset to convert to",
+				},
+				cli.StringFlag{
+					Name:  "valid-time",
+					Usage: "valid time of quote: 10s, 30s, 1m, 2m",
+				},
+				cli.BoolFlag{
+					Name:  "accept",
+					Usage: "accept the quote at once",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return convertQuote(c.String("from"), c.String("to"), c.String("amount"),
+					c.Bool("to-amount"), c.String("valid-time"), c.Bool("accept"))
+			},
+		},
+		{
+			Name:  "convert-accept",
+			Usage: "accept a quote of Binance Convert of the account of --name",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "quote-id",
+					Usage: "quote id from convert-quote",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return convertAccept(c.String("quote-id"))
+			},
+		},
+		{
+			Name:  "convert-history",
+			Usage: "list conversions of Binance Convert, last 30 days by default",
+			Flags: historyFlags,
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listConvertTrades(page)
+			},
+		},
+		{
+			Name:  "convert-dust",
+			Usage: "list small balances convertible to BNB or convert selected assets",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "assets",
+					Usage: "assets to convert: WINK,TRX, only list convertible balances if not set",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "preview BNB received without converting",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return convertDust(SplitItems(c.StringSlice("assets")), c.Bool("dry-run"))
+			},
+		},
+		{
+			Name:  "dust-log",
+			Usage: "list conversions of small balances to BNB with totals",
+			Flags: historyFlags,
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listDustLogs(page)
+			},
+		},
+		{
+			Name:  "account-snapshot",
+			Usage: "list daily snapshots of spot, margin or futures wallet",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "type",
+					Usage: "wallet type: SPOT, MARGIN or FUTURES",
+					Value: "SPOT",
+				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listAccountSnapshots(c.String("type"), page)
+			},
+		},
+		{
+			Name:  "staking-positions",
+			Usage: "list staking positions with APY, lock period and redemption dates",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "product",
+					Usage: "staking products: STAKING,F_DEFI,L_DEFI, all if not set",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return listStakingPositions(SplitItems(c.StringSlice("product")))
+			},
+		},
+		{
+			Name:  "auto-invest",
+			Usage: "list Auto-Invest plans, with execution history if --history is set",
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "history",
+					Usage: "also list executions of plans",
+				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listAutoInvest(c.Bool("history"), page)
+			},
+		},
+		{
+			Name:  "stake",
+			Usage: "subscribe to a staking product, list products with the products subcommand",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "product",
+					Usage: "staking product: STAKING, F_DEFI or L_DEFI",
+					Value: "STAKING",
+				},
+				cli.StringFlag{
+					Name:  "product-id",
+					Usage: "product id from stake products",
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "amount to stake",
+				},
+				cli.BoolFlag{
+					Name:  "renewable",
+					Usage: "auto renew the position at the end of lock period",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return stake(c.String("product"), c.String("product-id"), c.String("amount"), c.Bool("renewable"))
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "products",
+					Usage: "list staking products with APY, duration and quota",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "product",
+							Usage: "staking product: STAKING, F_DEFI or L_DEFI",
+							Value: "STAKING",
+						},
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "filter with asset: DOT",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listStakingProducts(c.String("product"), c.String("asset"))
+					},
+				},
+			},
+		},
+		{
+			Name:  "unstake",
+			Usage: "redeem a staking position",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "product",
+					Usage: "staking product: STAKING, F_DEFI or L_DEFI",
+					Value: "STAKING",
+				},
+				cli.StringFlag{
+					Name:  "product-id",
+					Usage: "product id of the position",
+				},
+				cli.StringFlag{
+					Name:  "position-id",
+					Usage: "position id, required by locked products",
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "amount to redeem, required by flexible products",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return unstake(c.String("product"), c.String("product-id"), c.String("position-id"), c.String("amount"))
+			},
+		},
+		{
+			Name:  "eth-staking",
+			Usage: "show ETH staking (WBETH) position, conversion rate and rewards",
+			Flags: historyFlags,
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return showLiquidStaking("ETH", page)
+			},
+		},
+		{
+			Name:  "sol-staking",
+			Usage: "show SOL staking (BNSOL) position, conversion rate and rewards",
+			Flags: historyFlags,
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return showLiquidStaking("SOL", page)
+			},
+		},
+		{
+			Name:  "account-status",
+			Usage: "check account and trading status of accounts for restrictions",
+			Action: func(c *cli.Context) error {
+				return listAccountStatus()
+			},
+		},
+		{
+			Name:  "key-permissions",
+			Usage: "audit API key permissions of accounts, flag keys with withdrawal or transfer enabled",
+			Action: func(c *cli.Context) error {
+				return auditKeyPermissions()
+			},
+		},
+		{
+			Name:  "bnb-burn",
+			Usage: "show or toggle using BNB for spot trading fees and margin interest",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "spot",
+					Usage: "use BNB for spot trading fees: on or off",
+				},
+				cli.StringFlag{
+					Name:  "interest",
+					Usage: "use BNB for margin interest: on or off",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return bnbBurn(c.String("spot"), c.String("interest"))
+			},
+		},
+		{
+			Name:  "trade-fee",
+			Usage: "show maker and taker commission rates of symbols for each account",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "symbols",
+					Usage: "symbols: BNBBTC,ETHBTC, also show rates after BNB discount, all symbols if not set",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return listTradeFees(SplitItems(c.StringSlice("symbols")))
+			},
+		},
+		{
+			Name:  "list-prices",
+			Usage: "list latest price for a symbol or symbols",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "filter with symbol",
+				},
+				cli.StringSliceFlag{
+					Name:  "symbols",
+					Usage: "filter with symbols: BTCUSDT,ETHUSDT",
+				},
+				cli.StringFlag{
+					Name:  "quote",
+					Usage: "filter with quote asset: USDT",
+				},
+				cli.StringFlag{
+					Name:  "sort",
+					Usage: "sort by price or volume in descending order",
+				},
+				refreshFlag,
+			},
+			Action: func(c *cli.Context) error {
+				symbols := SplitItems(c.StringSlice("symbols"))
+				if c.String("symbol") != "" {
+					symbols = append(symbols, c.String("symbol"))
+				}
+				return watchRefresh("list-prices", c.Duration("watch"), func() error {
+					return listPrices(symbols, c.String("quote"), c.String("sort"))
+				})
+			},
+		},
+		{
+			Name:  "ticker",
+			Usage: "show price change stats over a rolling window",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "symbol",
+					Usage: "symbol name: BNBBTC, can be repeated",
+				},
+				cli.StringFlag{
+					Name:  "window",
+					Usage: "rolling window size: 1m-59m, 1h-23h, 1d-7d",
+					Value: "1d",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return listRollingWindowStats(SplitItems(c.StringSlice("symbol")), c.String("window"))
+			},
+		},
+		{
+			Name:  "movers",
+			Usage: "list top gainers and losers by 24hr price change or volume",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "quote",
+					Usage: "filter with quote asset: USDT",
+				},
+				cli.StringFlag{
+					Name:  "by",
+					Usage: "rank by change or volume",
+					Value: "change",
+				},
+				cli.IntFlag{
+					Name:  "top",
+					Usage: "number of symbols to show",
+					Value: 10,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return listMovers(c.String("quote"), c.String("by"), c.Int("top"))
+			},
+		},
+		{
+			Name:  "klines",
+			Usage: "list klines of a symbol with optional technical indicators",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "symbol name: BNBBTC",
+				},
+				cli.StringFlag{
+					Name:  "interval",
+					Usage: "kline interval: 1m, 5m, 1h, 1d ...",
+					Value: "1h",
+				},
+				cli.IntFlag{
+					Name:  "limit",
+					Usage: "max number of klines",
+					Value: 100,
+				},
+				cli.StringSliceFlag{
+					Name:  "indicators",
+					Usage: "indicators with periods: sma:20,ema:50,rsi:14,macd:12:26:9,atr:14",
+				},
+			}, timeRangeFlags...),
+			Action: func(c *cli.Context) error {
+				startTime, endTime, err := parseTimeRange(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listKlines(c.String("symbol"), c.String("interval"), c.Int("limit"),
+					startTime, endTime, SplitItems(c.StringSlice("indicators")))
+			},
+		},
+		{
+			Name:  "convert-price",
+			Usage: "compute value of an amount of asset in another asset",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "asset to convert from: WINK",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "asset to convert to: USDT",
+				},
+				cli.Float64Flag{
+					Name:  "amount",
+					Usage: "amount of asset to convert",
+					Value: 1,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return convertPrice(c.String("from"), c.String("to"), c.Float64("amount"))
+			},
+		},
+		{
+			Name:  "mark-price",
+			Usage: "show mark, index and last price with funding of futures symbols",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "symbol",
+					Usage: "futures symbol name: BTCUSDT, all symbols if not set",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return listMarkPrices(SplitItems(c.StringSlice("symbol")))
+			},
+		},
+		{
+			Name:  "open-interest",
+			Usage: "show current or historical open interest of a futures symbol",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "futures symbol name: BTCUSDT",
+				},
+				cli.StringFlag{
+					Name:  "period",
+					Usage: "history period: 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d, current if not set",
+				},
+				cli.IntFlag{
+					Name:  "limit",
+					Usage: "max number of history records",
+					Value: 30,
+				},
+			}, append(timeRangeFlags, watchFlags...)...),
+			Action: func(c *cli.Context) error {
+				startTime, endTime, err := parseTimeRange(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listOpenInterest(c.String("symbol"), c.String("period"), c.Int("limit"),
+					startTime, endTime, parseMetricWatch(c))
+			},
+		},
+		{
+			Name:  "long-short-ratio",
+			Usage: "show long/short ratio of top traders or all accounts of a futures symbol",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "futures symbol name: BTCUSDT",
+				},
+				cli.StringFlag{
+					Name:  "type",
+					Usage: "ratio of top-account, top-position or global accounts",
+					Value: "top-account",
+				},
+				cli.StringFlag{
+					Name:  "period",
+					Usage: "period: 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d",
+					Value: "5m",
+				},
+				cli.IntFlag{
+					Name:  "limit",
+					Usage: "max number of history records",
+					Value: 30,
+				},
+			}, append(timeRangeFlags, watchFlags...)...),
+			Action: func(c *cli.Context) error {
+				startTime, endTime, err := parseTimeRange(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listLongShortRatio(c.String("type"), c.String("symbol"), c.String("period"),
+					c.Int("limit"), startTime, endTime, parseMetricWatch(c))
+			},
+		},
+		{
+			Name:  "taker-volume",
+			Usage: "show taker buy/sell volume and ratio of a futures symbol",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "futures symbol name: BTCUSDT",
+				},
+				cli.StringFlag{
+					Name:  "period",
+					Usage: "period: 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d",
+					Value: "5m",
+				},
+				cli.IntFlag{
+					Name:  "limit",
+					Usage: "max number of history records",
+					Value: 30,
+				},
+			}, append(timeRangeFlags, watchFlags...)...),
+			Action: func(c *cli.Context) error {
+				startTime, endTime, err := parseTimeRange(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listTakerVolume(c.String("symbol"), c.String("period"), c.Int("limit"),
+					startTime, endTime, parseMetricWatch(c))
+			},
+		},
+		{
+			Name:  "list-orders",
+			Usage: "list open orders",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "list orders with symbol",
+				},
+				refreshFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return watchRefresh("list-orders", c.Duration("watch"), func() error {
+					return listOpenOrders(c.String("symbol"))
+				})
+			},
+		},
+		{
+			Name:  "order-history",
+			Usage: "list orders of a symbol of all statuses, --from-id pages by order id",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "symbol of orders",
+				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listOrderHistory(c.String("symbol"), page)
+			},
+		},
+		{
+			Name:  "trade-history",
+			Usage: "list trades of a symbol, --from-id pages by trade id",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "symbol of trades",
+				},
+			}, historyFlags...),
+			Action: func(c *cli.Context) error {
+				page, err := parsePage(c)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return listTradeHistory(c.String("symbol"), page)
+			},
+		},
+		{
+			Name:  "order-timeline",
+			Usage: "show lifecycle of an order from creation to fills, amendments recorded by watch-account and cancellation",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "symbol name: BNBBTC",
+				},
+				cli.Int64Flag{
+					Name:  "order-id",
+					Usage: "order id",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return showOrderTimeline(c.String("symbol"), c.Int64("order-id"))
+			},
+		},
+		{
+			Name:  "create-order",
+			Usage: "create order",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "symbol name: BNBBTC",
+				},
+				cli.StringFlag{
+					Name:  "side",
+					Usage: "side type: SELL or BUY",
+				},
+				cli.StringFlag{
+					Name:  "quantity",
+					Usage: "quantity of symbol",
+				},
+				cli.StringFlag{
+					Name:  "price",
+					Usage: "price of symbol",
+				},
+				cli.StringFlag{
+					Name:  "peg",
+					Usage: "peg price to live book at submit time: mid, bid or ask",
+				},
+				cli.StringFlag{
+					Name:  "offset",
+					Usage: "offset of pegged price: 0.5 absolute or 5bps, negative lowers the price",
+				},
+				cli.StringFlag{
+					Name:  "strategy",
+					Usage: "strategy tag for execution quality report",
+				},
+				cli.IntFlag{
+					Name:  "retries",
+					Usage: "retry times on transient errors, landed orders are detected by client order id",
+				},
+				cli.DurationFlag{
+					Name:        "stagger",
+					Usage:       "delay between submissions to accounts: 2s",
+					Destination: &fanOutStagger,
+				},
+				cli.DurationFlag{
+					Name:        "jitter",
+					Usage:       "max random delay added to stagger",
+					Destination: &fanOutJitter,
+				},
+				cli.BoolFlag{
+					Name:        "shuffle",
+					Usage:       "submit to accounts in random order instead of by name",
+					Destination: &fanOutShuffle,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return createOrder(
+					c.String("symbol"), c.String("side"),
+					c.String("quantity"), c.String("price"), c.String("peg"), c.String("offset"),
+					c.String("strategy"), c.Int("retries"))
+			},
+		},
+		{
+			Name:  "futures",
+			Usage: "manage the USD-M futures account",
+			Subcommands: []cli.Command{
+				{
+					Name:  "balances",
+					Usage: "list non-zero balances of the futures wallet",
+					Action: func(c *cli.Context) error {
+						return listFuturesBalances()
+					},
+				},
+				{
+					Name:  "positions",
+					Usage: "list open positions with aggregate exposure across accounts",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "list positions with symbol",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listFuturesPositions(c.String("symbol"))
+					},
+				},
+				{
+					Name:  "list-orders",
+					Usage: "list open futures orders",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "list orders with symbol",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listFuturesOpenOrders(c.String("symbol"))
+					},
+				},
+				{
+					Name:  "create-order",
+					Usage: "create futures order",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.StringFlag{
+							Name:  "side",
+							Usage: "side type: SELL or BUY",
+						},
+						cli.StringFlag{
+							Name:  "type",
+							Usage: "order type: LIMIT, MARKET, STOP, STOP_MARKET, TAKE_PROFIT, TAKE_PROFIT_MARKET, TRAILING_STOP_MARKET",
+							Value: "LIMIT",
+						},
+						cli.StringFlag{
+							Name:  "quantity",
+							Usage: "quantity of symbol",
+						},
+						cli.StringFlag{
+							Name:  "price",
+							Usage: "price of symbol",
+						},
+						cli.StringFlag{
+							Name:  "stop-price",
+							Usage: "trigger price of STOP and TAKE_PROFIT orders",
+						},
+						cli.StringFlag{
+							Name:  "callback-rate",
+							Usage: "callback rate in percent of TRAILING_STOP_MARKET orders: 0.1 to 5",
+						},
+						cli.StringFlag{
+							Name:  "activation-price",
+							Usage: "activation price of TRAILING_STOP_MARKET orders, current price if not set",
+						},
+						cli.StringFlag{
+							Name:  "position-side",
+							Usage: "position side in hedge mode: LONG or SHORT",
+						},
+						cli.BoolFlag{
+							Name:  "reduce-only",
+							Usage: "only reduce the position, not sent with position-side",
+						},
+						cli.BoolFlag{
+							Name:  "close-position",
+							Usage: "close the whole position when STOP_MARKET or TAKE_PROFIT_MARKET is triggered",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return createFuturesOrder(&FuturesOrderRequest{
+							Symbol:          c.String("symbol"),
+							Side:            c.String("side"),
+							Type:            c.String("type"),
+							Quantity:        c.String("quantity"),
+							Price:           c.String("price"),
+							StopPrice:       c.String("stop-price"),
+							CallbackRate:    c.String("callback-rate"),
+							ActivationPrice: c.String("activation-price"),
+							PositionSide:    c.String("position-side"),
+							ReduceOnly:      c.Bool("reduce-only"),
+							ClosePosition:   c.Bool("close-position"),
+						})
+					},
+				},
+				{
+					Name:  "get-order",
+					Usage: "get a futures order",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.Int64Flag{
+							Name:  "order-id",
+							Usage: "order id",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return getFuturesOrder(c.String("symbol"), c.Int64("order-id"))
+					},
+				},
+				{
+					Name:  "cancel-order",
+					Usage: "cancel a futures order",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.Int64Flag{
+							Name:  "order-id",
+							Usage: "order id",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return cancelFuturesOrder(c.String("symbol"), c.Int64("order-id"))
+					},
+				},
+				{
+					Name:  "cancel-orders",
+					Usage: "cancel open futures orders",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "cancel open orders with symbol",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return cancelFuturesOrders(c.String("symbol"))
+					},
+				},
+				{
+					Name:  "close",
+					Usage: "flatten position of a symbol with a market reduce-only order",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.Float64Flag{
+							Name:  "percent",
+							Usage: "percent of position to close",
+							Value: 100,
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return closeFuturesPosition(c.String("symbol"), c.Float64("percent"))
+					},
+				},
+				{
+					Name:  "transfer",
+					Usage: "transfer collateral between spot and futures wallets",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "wallet to transfer to: futures or spot",
+							Value: "futures",
+						},
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "asset name: USDT",
+						},
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "amount to transfer",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return transferFutures(c.String("to"), c.String("asset"), c.String("amount"))
+					},
+				},
+				{
+					Name:  "transfer-history",
+					Usage: "list transfers between spot and futures wallets in both directions",
+					Flags: historyFlags,
+					Action: func(c *cli.Context) error {
+						page, err := parsePage(c)
+						if err != nil {
+							return errors.Trace(err)
+						}
+						return listTransfers("SPOT", "FUTURES", true, page)
+					},
+				},
+				{
+					Name:  "set-leverage",
+					Usage: "set leverage of a symbol and show the resulting max notional",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.IntFlag{
+							Name:  "leverage",
+							Usage: "leverage from 1 to 125",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return setFuturesLeverage(c.String("symbol"), c.Int("leverage"))
+					},
+				},
+				{
+					Name:  "margin-type",
+					Usage: "switch margin type of a symbol or adjust isolated margin of its position",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.StringFlag{
+							Name:  "type",
+							Usage: "margin type: ISOLATED or CROSSED",
+						},
+						cli.StringFlag{
+							Name:  "add",
+							Usage: "amount of margin to add to the isolated position",
+						},
+						cli.StringFlag{
+							Name:  "reduce",
+							Usage: "amount of margin to remove from the isolated position",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return setFuturesMarginType(c.String("symbol"), c.String("type"), c.String("add"), c.String("reduce"))
+					},
+				},
+				{
+					Name:  "funding-history",
+					Usage: "list funding payments received and paid with totals by symbol, recent 7 days by default",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "filter with symbol: BTCUSDT",
+						},
+					}, historyFlags...),
+					Action: func(c *cli.Context) error {
+						page, err := parsePage(c)
+						if err != nil {
+							return errors.Trace(err)
+						}
+						return listFundingHistory(c.String("symbol"), page)
+					},
+				},
+				{
+					Name:  "income",
+					Usage: "list income history with totals by type and net performance, recent 7 days by default",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "filter with symbol: BTCUSDT",
+						},
+						cli.StringSliceFlag{
+							Name:  "type",
+							Usage: "income types: REALIZED_PNL,COMMISSION,FUNDING_FEE, all types if not set",
+						},
+					}, historyFlags...),
+					Action: func(c *cli.Context) error {
+						page, err := parsePage(c)
+						if err != nil {
+							return errors.Trace(err)
+						}
+						return listFuturesIncome(c.String("symbol"), SplitItems(c.StringSlice("type")), page)
+					},
+				},
+				{
+					Name:  "liq-price",
+					Usage: "compute liquidation price of existing positions, or of a hypothetical position if --quantity is set",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BTCUSDT",
+						},
+						cli.StringFlag{
+							Name:  "side",
+							Usage: "side of hypothetical position: BUY or SELL",
+							Value: "BUY",
+						},
+						cli.StringFlag{
+							Name:  "quantity",
+							Usage: "quantity of hypothetical position",
+						},
+						cli.StringFlag{
+							Name:  "entry-price",
+							Usage: "entry price of hypothetical position",
+						},
+						cli.IntFlag{
+							Name:  "leverage",
+							Usage: "leverage of hypothetical position, margin is notional / leverage",
+						},
+						cli.StringFlag{
+							Name:  "margin",
+							Usage: "isolated margin of hypothetical position, overrides leverage",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						if c.String("quantity") == "" {
+							return listLiquidationPrices(c.String("symbol"))
+						}
+						return estimateLiquidation(c.String("symbol"), c.String("side"), c.String("quantity"),
+							c.String("entry-price"), c.String("margin"), c.Int("leverage"))
+					},
+				},
+			},
+		},
+		{
+			Name:  "margin",
+			Usage: "manage the cross margin account",
+			Subcommands: []cli.Command{
+				{
+					Name:  "balances",
+					Usage: "show margin level and non-zero assets with borrowed and interest",
+					Action: func(c *cli.Context) error {
+						return listMarginBalances()
+					},
+				},
+				{
+					Name:  "list-orders",
+					Usage: "list open margin orders",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "list orders with symbol",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listMarginOpenOrders(c.String("symbol"))
+					},
+				},
+				{
+					Name:  "create-order",
+					Usage: "create limit margin order",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "symbol name: BNBBTC",
+						},
+						cli.StringFlag{
+							Name:  "side",
+							Usage: "side type: SELL or BUY",
+						},
+						cli.StringFlag{
+							Name:  "quantity",
+							Usage: "quantity of symbol",
+						},
+						cli.StringFlag{
+							Name:  "price",
+							Usage: "price of symbol",
+						},
+						cli.StringFlag{
+							Name:  "side-effect",
+							Usage: "side effect: NO_SIDE_EFFECT, MARGIN_BUY to borrow, AUTO_REPAY to repay",
+							Value: "NO_SIDE_EFFECT",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return createMarginOrder(c.String("symbol"), c.String("side"),
+							c.String("quantity"), c.String("price"), c.String("side-effect"))
+					},
+				},
+				{
+					Name:  "cancel-orders",
+					Usage: "cancel open margin orders",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "symbol",
+							Usage: "cancel open orders with symbol",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return cancelMarginOrders(c.String("symbol"))
+					},
+				},
+				{
+					Name:  "borrow",
+					Usage: "borrow asset in the cross margin account",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "asset name: USDT",
+						},
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "amount to borrow",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return borrowRepay("BORROW", c.String("asset"), c.String("amount"))
+					},
+				},
+				{
+					Name:  "repay",
+					Usage: "repay debt of asset in the cross margin account",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "asset name: USDT",
+						},
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "amount to repay",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return borrowRepay("REPAY", c.String("asset"), c.String("amount"))
+					},
+				},
+				{
+					Name:  "loans-history",
+					Usage: "list borrows and repays of the cross margin account",
+					Flags: append([]cli.Flag{
+						cli.StringSliceFlag{
+							Name:  "type",
+							Usage: "loan types: borrow,repay, both if not set",
+						},
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "filter with asset: USDT",
+						},
+					}, historyFlags...),
+					Action: func(c *cli.Context) error {
+						page, err := parsePage(c)
+						if err != nil {
+							return errors.Trace(err)
+						}
+						return listMarginLoans(SplitItems(c.StringSlice("type")), c.String("asset"), page)
+					},
+				},
+				{
+					Name:  "max-borrowable",
+					Usage: "show max borrowable amount of assets",
+					Flags: []cli.Flag{
+						cli.StringSliceFlag{
+							Name:  "assets",
+							Usage: "asset names: BTC,USDT",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listMaxBorrowable(SplitItems(c.StringSlice("assets")))
+					},
+				},
+				{
+					Name:  "interest-rate",
+					Usage: "show next hourly interest rate of assets with daily and yearly rates, cheapest first",
+					Flags: []cli.Flag{
+						cli.StringSliceFlag{
+							Name:  "assets",
+							Usage: "asset names: BTC,USDT",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listMarginInterestRates(SplitItems(c.StringSlice("assets")))
+					},
+				},
+			},
+		},
+		{
+			Name:  "subaccount",
+			Usage: "manage sub-accounts of master accounts",
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "list sub-accounts",
+					Action: func(c *cli.Context) error {
+						return listSubAccounts()
+					},
+				},
+				{
+					Name:  "balances",
+					Usage: "list non-zero spot balances of sub-accounts",
+					Flags: []cli.Flag{
+						cli.StringSliceFlag{
+							Name:  "email",
+							Usage: "emails of sub-accounts, all sub-accounts if not set",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return listSubAccountBalances(SplitItems(c.StringSlice("email")))
+					},
+				},
+				{
+					Name:  "transfer",
+					Usage: "transfer asset between master and sub-accounts, or between sub-accounts",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "from-email",
+							Usage: "sub-account to transfer from, master account if not set",
+						},
+						cli.StringFlag{
+							Name:  "to-email",
+							Usage: "sub-account to transfer to, master account if not set",
+						},
+						cli.StringFlag{
+							Name:  "from-wallet",
+							Usage: "wallet to transfer from: SPOT, MARGIN, FUTURES, COIN-FUTURES",
+							Value: "SPOT",
+						},
+						cli.StringFlag{
+							Name:  "to-wallet",
+							Usage: "wallet to transfer to: SPOT, MARGIN, FUTURES, COIN-FUTURES",
+							Value: "SPOT",
+						},
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "asset name: USDT",
+						},
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "amount to transfer",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return transferSubAccount(c.String("from-email"), c.String("to-email"),
+							c.String("from-wallet"), c.String("to-wallet"), c.String("asset"), c.String("amount"))
+					},
+				},
+				{
+					Name:  "transfer-history",
+					Usage: "list transfers between master and sub-accounts, from master account if no email is set",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "from-email",
+							Usage: "list transfers from sub-account",
+						},
+						cli.StringFlag{
+							Name:  "to-email",
+							Usage: "list transfers to sub-account",
+						},
+					}, historyFlags...),
+					Action: func(c *cli.Context) error {
+						page, err := parsePage(c)
+						if err != nil {
+							return errors.Trace(err)
+						}
+						return listSubAccountTransfers(c.String("from-email"), c.String("to-email"), page)
+					},
+				},
+			},
+		},
+		{
+			Name:  "positions",
+			Usage: "manage open spot positions tracked for pnl",
+			Subcommands: []cli.Command{
+				{
+					Name:  "bootstrap",
+					Usage: "derive net inventory and average cost of symbols from full trade history",
+					Flags: []cli.Flag{
+						cli.StringSliceFlag{
+							Name:  "symbols",
+							Usage: "symbols to bootstrap: BNBBTC,BTCUSDT, default to symbols of account in keyfile",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return bootstrapPositions(SplitItems(c.StringSlice("symbols")))
+					},
+				},
+				{
+					Name:  "show",
+					Usage: "show saved positions",
+					Action: func(c *cli.Context) error {
+						return showPositions()
+					},
+				},
+			},
+		},
+		{
+			Name:  "execution-report",
+			Usage: "compare fill prices of orders created by the CLI with arrival mid prices",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "strategy",
+					Usage: "filter with strategy tag",
+				},
+				cli.StringFlag{
+					Name:  "start-time",
+					Usage: "only orders created since: 2018-01-02, RFC3339 or relative like 7d",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				startTime, err := ParseTime(c.String("start-time"))
+				if err != nil {
+					return errors.Trace(err)
+				}
+				return executionReport(c.String("strategy"), startTime)
+			},
+		},
+		{
+			Name:  "cancel-orders",
+			Usage: "cancel open orders",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "symbol",
+					Usage: "cancel open orders with symbol",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return cancelOrders(c.String("symbol"))
+			},
+		},
+		{
+			Name:  "fix-permissions",
+			Usage: "restrict keyfile and local state to the current user",
+			Action: func(c *cli.Context) error {
+				return fixPermissions()
+			},
+		},
+		{
+			Name:  "history",
+			Usage: "search previous commands, or run one again with --rerun",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "limit",
+					Usage: "max number of latest commands to show",
+					Value: 20,
+				},
+				cli.IntFlag{
+					Name:  "rerun",
+					Usage: "id of command to run again",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "rerun without confirmation, withdrawals are still confirmed",
+				},
+			},
+			ArgsUsage: "[keywords...]",
+			Action: func(c *cli.Context) error {
+				skipHistory = true
+				if c.Int("rerun") > 0 {
+					return rerunHistory(c.Int("rerun"), c.Bool("yes"))
+				}
+				return searchHistory(c.Args(), c.Int("limit"))
+			},
+		},
+		{
+			Name:   "watch-streams",
+			Usage:  "print raw events of streams over a single combined connection",
+			Before: checkStreamFormat,
+			Flags: []cli.Flag{
+				streamFormatFlag,
+				cli.StringSliceFlag{
+					Name:  "stream",
+					Usage: "stream name: bnbbtc@ticker,btcusdt@depth5, can be repeated",
+				},
+				cli.BoolFlag{
+					Name:  "futures",
+					Usage: "subscribe USD-M futures streams",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return watchStreams(SplitItems(c.StringSlice("stream")), c.Bool("futures"))
+			},
+		},
+		{
+			Name:   "watch-prices",
+			Usage:  "print live prices of symbols from ticker streams",
+			Before: checkStreamFormat,
+			Flags: []cli.Flag{
+				streamFormatFlag,
+				cli.StringSliceFlag{
+					Name:  "symbols",
+					Usage: "symbols to watch: BNBBTC,BTCUSDT, default to symbols of accounts in keyfile",
+				},
+				cli.Float64Flag{
+					Name:  "threshold",
+					Usage: "only print when price moves by percent since last printed: 0.5",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return watchPrices(SplitItems(c.StringSlice("symbols")), c.Float64("threshold"))
+			},
+		},
+		{
+			Name:   "watch-depth",
+			Usage:  "print live order books of symbols with best bid/ask summary",
+			Before: checkStreamFormat,
+			Flags: []cli.Flag{
+				streamFormatFlag,
+				cli.StringSliceFlag{
+					Name:  "symbol",
+					Usage: "symbol names: BNBBTC,BTCUSDT, can be repeated, default to symbols of accounts in keyfile",
+				},
+				cli.IntFlag{
+					Name:  "levels",
+					Usage: "number of levels to show, 5, 10 or 20 for partial depth",
+					Value: 10,
+				},
+				cli.BoolFlag{
+					Name:  "local",
+					Usage: "maintain a local book merged from diff updates instead of partial depth",
+				},
+				cli.StringFlag{
+					Name:  "rate",
+					Usage: "update speed of stream: 100ms or 1000ms",
+					Value: "1000ms",
+				},
+				cli.BoolFlag{
+					Name:  "summary",
+					Usage: "only print best bid/ask and spread",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return watchDepth(SplitItems(c.StringSlice("symbol")), c.Int("levels"), c.Bool("local"), c.String("rate"), c.Bool("summary"))
+			},
+		},
+		{
+			Name:   "watch-trades",
+			Usage:  "print live public trades of symbols with a rolling VWAP and volume summary",
+			Before: checkStreamFormat,
+			Flags: []cli.Flag{
+				streamFormatFlag,
+				cli.StringSliceFlag{
+					Name:  "symbol",
+					Usage: "symbol names: BNBBTC,BTCUSDT, can be repeated, default to symbols of accounts in keyfile",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "interval of summaries",
+					Value: 10 * time.Second,
+				},
+				cli.DurationFlag{
+					Name:  "window",
+					Usage: "rolling window of summaries, same as interval if not set",
+				},
+				cli.BoolFlag{
+					Name:  "summary",
+					Usage: "only print summaries",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return watchTrades(SplitItems(c.StringSlice("symbol")), c.Duration("interval"), c.Duration("window"), c.Bool("summary"))
+			},
+		},
+		{
+			Name:   "watch-klines",
+			Usage:  "print klines of symbols as they close with optional technical indicators",
+			Before: checkStreamFormat,
+			Flags: []cli.Flag{
+				streamFormatFlag,
+				cli.StringSliceFlag{
+					Name:  "symbol",
+					Usage: "symbol names: BNBBTC,BTCUSDT, can be repeated, default to symbols of accounts in keyfile",
+				},
+				cli.StringFlag{
+					Name:  "interval",
+					Usage: "kline interval: 1m, 5m, 1h, 1d ...",
+					Value: "1m",
+				},
+				cli.StringSliceFlag{
+					Name:  "indicators",
+					Usage: "indicators with periods: sma:20,ema:50,rsi:14,macd:12:26:9,atr:14",
+				},
+				cli.IntFlag{
+					Name:  "history",
+					Usage: "number of closed klines to compute indicators over",
+					Value: 200,
+				},
+				cli.BoolFlag{
+					Name:  "live",
+					Usage: "also print updates of the open kline",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return watchKlines(SplitItems(c.StringSlice("symbol")), c.String("interval"), c.Int("history"),
+					SplitItems(c.StringSlice("indicators")), c.Bool("live"))
+			},
+		},
+		{
+			Name:   "watch-account",
+			Usage:  "print order updates and balance changes of accounts from user data streams",
+			Before: checkStreamFormat,
+			Flags: []cli.Flag{
+				streamFormatFlag,
+				cli.StringFlag{
+					Name:  "on-fill",
+					Usage: "notify filled orders as JSON to a command on stdin or an HTTP endpoint by POST",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return watchAccounts(c.String("on-fill"))
+			},
+		},
+		{
+			Name:  "dashboard",
+			Usage: "show live balances, open orders and prices of symbols across accounts in the terminal",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "symbols",
+					Usage: "symbols to show prices of: BNBBTC,BTCUSDT, default symbols of accounts and open orders",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "minimum interval of redrawing",
+					Value: time.Second,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return runDashboard(SplitItems(c.StringSlice("symbols")), c.Duration("interval"))
+			},
+		},
+		{
+			Name:  "heartbeat",
+			Usage: "record operator heartbeat for the dead man's switch",
+			Action: func(c *cli.Context) error {
+				return heartbeat()
+			},
+		},
+		{
+			Name:  "deadman",
+			Usage: "cancel all open orders when no heartbeat is received in time",
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "max duration between heartbeats",
+					Value: time.Hour,
+				},
+				cli.DurationFlag{
+					Name:  "check-interval",
+					Usage: "duration between heartbeat checks",
+					Value: 30 * time.Second,
+				},
+				cli.BoolFlag{
+					Name:  "flatten-futures",
+					Usage: "also close all USD-M futures positions with market orders when the switch fires",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return runDeadman(c.Duration("interval"), c.Duration("check-interval"), c.Bool("flatten-futures"))
+			},
+		},
+		{
+			Name:  "guard",
+			Usage: "learn typical orders and withdrawals of accounts and alert on anomalies",
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "duration between activity checks",
+					Value: time.Minute,
+				},
+				cli.Float64Flag{
+					Name:  "factor",
+					Usage: "alert when activity exceeds typical rate by this factor",
+					Value: 3,
+				},
+				cli.IntFlag{
+					Name:  "min-burst",
+					Usage: "min number of new orders not created by the CLI to alert on",
+					Value: 5,
+				},
+				cli.StringFlag{
+					Name:  "alert-cmd",
+					Usage: "command run with alert message on stdin",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return runGuard(c.Duration("interval"), c.Float64("factor"), c.Int("min-burst"),
+					c.String("alert-cmd"))
+			},
+		},
+		{
+			Name:  "compare",
+			Usage: "rank accounts by return, volume, fees and win rate over a period",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "symbols",
+					Usage: "symbols to compare: BNBBTC, BTCUSDT ..., default to symbols of account in keyfile",
+				},
+				cli.IntFlag{
+					Name:  "days",
+					Usage: "compare trades of recent days",
+					Value: 30,
+				},
+				cli.StringFlag{
+					Name:  "start-time",
+					Usage: "start time, overrides days: 2018-01-02, RFC3339 or relative like 7d",
+				},
+				cli.StringFlag{
+					Name:  "end-time",
+					Usage: "end time: 2018-01-02, RFC3339 or relative like 7d, default now",
+				},
+				cli.StringFlag{
+					Name:  "sort-by",
+					Usage: "rank by return, pnl, volume, fees or win-rate",
+					Value: "return",
+				},
+				cli.StringFlag{
+					Name:  "quote",
+					Usage: "quote asset of volume, fees and pnl: USDT, BTC ...",
+					Value: "USDT",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				endTime, err := ParseTime(c.String("end-time"))
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if endTime == 0 {
+					endTime = MilliTime(time.Now())
+				}
+				startTime, err := ParseTime(c.String("start-time"))
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if startTime == 0 {
+					startTime = endTime - int64(c.Int("days"))*int64(24*time.Hour/time.Millisecond)
+				}
+				return compareAccounts(SplitItems(c.StringSlice("symbols")), startTime, endTime, c.String("sort-by"),
+					c.String("quote"))
+			},
+		},
+		{
+			Name:  "shell",
+			Usage: "run commands interactively with history, tab completion and `use account` to select an account",
+			Action: func(c *cli.Context) error {
+				skipHistory = true
+				// global flags before shell apply to every command
+				globals := os.Args[1 : len(os.Args)-len(c.Args())-1]
+				return newShell(c.App, globals).Run()
+			},
+		},
+		{
+			Name:  "encrypt-keys",
+			Usage: "encrypt the keyfile with a passphrase, read from " + passphraseEnv + " or prompted",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output",
+					Usage: "file path of the encrypted keyfile, default to replace keyfile",
+				},
+				cli.BoolFlag{
+					Name:  "decrypt",
+					Usage: "decrypt an encrypted keyfile instead, to edit keys",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return encryptKeyfile(keyfile, c.String("output"), c.Bool("decrypt"))
+			},
+		},
+		{
+			Name:  "store-secrets",
+			Usage: "move secret keys of the keyfile into the OS keyring, used with --key-backend keyring",
+			Action: func(c *cli.Context) error {
+				return storeSecrets()
+			},
+		},
+		{
+			Name:      "completion",
+			Usage:     "print the completion script of a shell: bash, zsh or fish",
+			ArgsUsage: "bash|zsh|fish",
+			Action: func(c *cli.Context) error {
+				skipHistory = true
+				script, err := completionScript(c.App, c.Args().First())
+				if err != nil {
+					return errors.Trace(err)
+				}
+				fmt.Print(script)
+				return nil
+			},
+		},
+		{
+			Name:      "complete-names",
+			Usage:     "print names completed by completion scripts",
+			ArgsUsage: "accounts|symbols",
+			Hidden:    true,
+			Action: func(c *cli.Context) error {
+				skipHistory = true
+				names, err := completionNames(c.Args().First())
+				if err != nil {
+					return errors.Trace(err)
+				}
+				for _, name := range names {
+					fmt.Println(name)
+				}
+				return nil
 			},
 		},
 	}
+	trackCommands(app.Commands, "")
 	err := app.Run(os.Args)
+	recordHistory(os.Args[1:], err)
 	if err != nil {
-		log.Fatal(errors.ErrorStack(err))
+		logError(err)
+		os.Exit(1)
 	}
 }
 
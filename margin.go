@@ -0,0 +1,385 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// MarginAsset define balance and debt of an asset in the cross margin account
+type MarginAsset struct {
+	Asset    string `json:"asset"`
+	Free     string `json:"free"`
+	Locked   string `json:"locked"`
+	Borrowed string `json:"borrowed"`
+	Interest string `json:"interest"`
+	NetAsset string `json:"netAsset"`
+}
+
+// MarginAccount define the cross margin account
+type MarginAccount struct {
+	BorrowEnabled       bool           `json:"borrowEnabled"`
+	MarginLevel         string         `json:"marginLevel"`
+	TotalAssetOfBtc     string         `json:"totalAssetOfBtc"`
+	TotalLiabilityOfBtc string         `json:"totalLiabilityOfBtc"`
+	TotalNetAssetOfBtc  string         `json:"totalNetAssetOfBtc"`
+	TradeEnabled        bool           `json:"tradeEnabled"`
+	TransferEnabled     bool           `json:"transferEnabled"`
+	UserAssets          []*MarginAsset `json:"userAssets"`
+}
+
+// GetMarginAccount get the cross margin account
+func (account *Account) GetMarginAccount() (*MarginAccount, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(MarginAccount)
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/margin/account", nil, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// ListMarginOpenOrders list open orders of the cross margin account of symbol, all symbols if empty
+func (account *Account) ListMarginOpenOrders(symbol string) ([]*binance.Order, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", strings.ToUpper(symbol))
+	}
+	var orders []*binance.Order
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/margin/openOrders", params, true, &orders)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return orders, nil
+}
+
+// CreateMarginOrder create a limit order in the cross margin account with side
+// effect: NO_SIDE_EFFECT, MARGIN_BUY to borrow or AUTO_REPAY to repay debt
+func (account *Account) CreateMarginOrder(symbol, side, quantity, price, sideEffect string) (*binance.CreateOrderResponse, error) {
+	symbol, side = strings.ToUpper(symbol), strings.ToUpper(side)
+	err := checkTrade(&TradeCheck{
+		Account:  account.Name,
+		Market:   "margin",
+		Symbol:   symbol,
+		Side:     side,
+		Type:     string(binance.OrderTypeLimit),
+		Quantity: quantity,
+		Price:    price,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":           {symbol},
+		"side":             {side},
+		"type":             {string(binance.OrderTypeLimit)},
+		"timeInForce":      {string(binance.TimeInForceTypeGTC)},
+		"quantity":         {quantity},
+		"price":            {price},
+		"newClientOrderId": {newClientOrderID()},
+	}
+	if sideEffect != "" {
+		params.Set("sideEffectType", strings.ToUpper(sideEffect))
+	}
+	res := new(binance.CreateOrderResponse)
+	err = account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/margin/order", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// CancelMarginOrder cancel an order of the cross margin account
+func (account *Account) CancelMarginOrder(symbol string, orderID int64) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"symbol":  {symbol},
+		"orderId": {strconv.FormatInt(orderID, 10)},
+	}
+	err := account.callAPI(ctx, http.MethodDelete, apiURL, "/sapi/v1/margin/order", params, true, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// CancelMarginOpenOrders cancel open margin orders of symbol, all symbols if empty
+func (account *Account) CancelMarginOpenOrders(symbol string) ([]int64, error) {
+	var canceledOrders []int64
+	orders, err := account.ListMarginOpenOrders(symbol)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, order := range orders {
+		err = account.CancelMarginOrder(order.Symbol, order.OrderID)
+		if err != nil {
+			return canceledOrders, errors.Trace(err)
+		}
+		canceledOrders = append(canceledOrders, order.OrderID)
+	}
+	return canceledOrders, nil
+}
+
+// listMarginBalances list the cross margin account with non-zero assets only
+func listMarginBalances() error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		margin, err := account.GetMarginAccount()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var assets []*MarginAsset
+		for _, a := range margin.UserAssets {
+			if StrToFloat(a.Free) != 0 || StrToFloat(a.Locked) != 0 ||
+				StrToFloat(a.Borrowed) != 0 || StrToFloat(a.Interest) != 0 {
+				assets = append(assets, a)
+			}
+		}
+		margin.UserAssets = assets
+		return margin, nil
+	})
+}
+
+func listMarginOpenOrders(symbol string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		orders, err := account.ListMarginOpenOrders(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return orders, nil
+	})
+}
+
+func createMarginOrder(symbol, side, quantity, price, sideEffect string) error {
+	if symbol == "" || quantity == "" || price == "" {
+		return errors.New("symbol, quantity and price required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		res, err := account.CreateMarginOrder(symbol, side, quantity, price, sideEffect)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return res.OrderID, nil
+	})
+}
+
+func cancelMarginOrders(symbol string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		canceledOrders, err := account.CancelMarginOpenOrders(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return canceledOrders, nil
+	})
+}
+
+// MarginLoan define a borrow or repay of the cross margin account
+type MarginLoan struct {
+	TxID      int64  `json:"txId"`
+	Type      string `json:"type"`
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	Principal string `json:"principal,omitempty"`
+	Interest  string `json:"interest,omitempty"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// BorrowRepay borrow or repay amount of asset in the cross margin account,
+// typ is BORROW or REPAY
+func (account *Account) BorrowRepay(typ, asset, amount string) (int64, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"type":       {typ},
+		"asset":      {strings.ToUpper(asset)},
+		"amount":     {amount},
+		"isIsolated": {"FALSE"},
+	}
+	res := new(struct {
+		TranID int64 `json:"tranId"`
+	})
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/margin/borrow-repay", params, true, res)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return res.TranID, nil
+}
+
+// ListMarginLoans list borrows or repays of asset between startTime and
+// endTime, typ is BORROW or REPAY, all assets if asset is empty
+func (account *Account) ListMarginLoans(typ, asset string, startTime, endTime int64) ([]*MarginLoan, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var loans []*MarginLoan
+	for current := 1; ; current++ {
+		params := url.Values{
+			"type":    {typ},
+			"current": {strconv.Itoa(current)},
+			"size":    {"100"},
+		}
+		if asset != "" {
+			params.Set("asset", strings.ToUpper(asset))
+		}
+		setTimeRange(params, 0, startTime, endTime)
+		res := new(struct {
+			Total int           `json:"total"`
+			Rows  []*MarginLoan `json:"rows"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/margin/borrow-repay", params, true, res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, loan := range res.Rows {
+			loan.Type = typ
+		}
+		loans = append(loans, res.Rows...)
+		if len(res.Rows) == 0 || len(loans) >= res.Total {
+			return loans, nil
+		}
+	}
+}
+
+// borrowRepay borrow or repay amount of asset on each account
+func borrowRepay(typ, asset, amount string) error {
+	if asset == "" || amount == "" {
+		return errors.New("asset and amount required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		tranID, err := account.BorrowRepay(typ, asset, amount)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return tranID, nil
+	})
+}
+
+// marginLoanSpan is the max time range of a request of margin loans
+const marginLoanSpan = 30 * 24 * time.Hour
+
+// listMarginLoans list borrows and repays of types: borrow, repay, both if
+// empty, latest first
+func listMarginLoans(types []string, asset string, page *Page) error {
+	if len(types) == 0 {
+		types = []string{"borrow", "repay"}
+	}
+	for i, typ := range types {
+		types[i] = strings.ToUpper(typ)
+		if types[i] != "BORROW" && types[i] != "REPAY" {
+			return errors.Errorf("invalid loan type: %s", typ)
+		}
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var loans []*MarginLoan
+		for _, typ := range types {
+			err := page.Fetch(marginLoanSpan, func(startTime, endTime int64) error {
+				res, err := account.ListMarginLoans(typ, asset, startTime, endTime)
+				loans = append(loans, res...)
+				return errors.Trace(err)
+			})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		sort.SliceStable(loans, func(i, j int) bool {
+			return loans[i].Timestamp > loans[j].Timestamp
+		})
+		return page.Apply(loans), nil
+	})
+}
+
+// MaxBorrowable define max amount of an asset borrowable in the cross margin account
+type MaxBorrowable struct {
+	Asset       string `json:"asset"`
+	Amount      string `json:"amount"`
+	BorrowLimit string `json:"borrowLimit"`
+}
+
+// GetMaxBorrowable get max borrowable amount of asset in the cross margin account
+func (account *Account) GetMaxBorrowable(asset string) (*MaxBorrowable, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := &MaxBorrowable{Asset: strings.ToUpper(asset)}
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/margin/maxBorrowable",
+		url.Values{"asset": {res.Asset}}, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// MarginInterestRate define the cross margin interest rate of an asset for
+// the next hour with daily and yearly rates derived from it
+type MarginInterestRate struct {
+	Asset      string  `json:"asset"`
+	HourlyRate string  `json:"nextHourlyInterestRate"`
+	DailyRate  float64 `json:"daily_rate"`
+	YearlyRate float64 `json:"yearly_rate"`
+}
+
+// ListMarginInterestRates list next hourly interest rates of assets of the
+// cross margin account, the rates depend on the VIP level of account
+func (account *Account) ListMarginInterestRates(assets []string) ([]*MarginInterestRate, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"assets":     {strings.ToUpper(strings.Join(assets, ","))},
+		"isIsolated": {"FALSE"},
+	}
+	var rates []*MarginInterestRate
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/margin/next-hourly-interest-rate", params, true, &rates)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, rate := range rates {
+		hourly := StrToFloat(rate.HourlyRate)
+		rate.DailyRate = hourly * 24
+		rate.YearlyRate = hourly * 24 * 365
+	}
+	return rates, nil
+}
+
+func listMaxBorrowable(assets []string) error {
+	if len(assets) == 0 {
+		return errors.New("assets required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var res []*MaxBorrowable
+		for _, asset := range assets {
+			borrowable, err := account.GetMaxBorrowable(asset)
+			if err != nil {
+				return nil, errors.Annotatef(err, "asset %s", asset)
+			}
+			res = append(res, borrowable)
+		}
+		return res, nil
+	})
+}
+
+// listMarginInterestRates list interest rates of assets, cheapest first
+func listMarginInterestRates(assets []string) error {
+	if len(assets) == 0 {
+		return errors.New("assets required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		rates, err := account.ListMarginInterestRates(assets)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].DailyRate < rates[j].DailyRate
+		})
+		return rates, nil
+	})
+}
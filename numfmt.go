@@ -0,0 +1,161 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+)
+
+// number formats of table output
+const (
+	numbersRaw     = "raw"
+	numbersGrouped = "grouped"
+	numbersShort   = "short"
+)
+
+var (
+	// numberFormat is the format of numbers in table output: raw, grouped
+	// with thousands separators or short with 12.3K abbreviations
+	numberFormat string
+	// numberDecimals is the decimal places of numbers in table output, -1
+	// to keep them as returned
+	numberDecimals int
+	// timeZone is the name of the time zone of times in table output,
+	// local time if empty
+	timeZone     string
+	timeLocation = time.Local
+)
+
+// numberUnit define an abbreviation of numbers of at least size
+type numberUnit struct {
+	size   float64
+	suffix string
+}
+
+// numberUnits define abbreviations of short numbers by language, largest
+// first. Chinese groups by ten thousands.
+var numberUnits = map[string][]numberUnit{
+	langEN:   {{1e12, "T"}, {1e9, "B"}, {1e6, "M"}, {1e3, "K"}},
+	langZhCN: {{1e12, "万亿"}, {1e8, "亿"}, {1e4, "万"}},
+}
+
+// initNumbers check the number format and load the time zone of table output
+func initNumbers() error {
+	switch numberFormat {
+	case numbersRaw, numbersGrouped, numbersShort:
+	default:
+		return errors.Errorf("invalid number format: %s", numberFormat)
+	}
+	if timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return errors.Annotatef(err, "invalid time zone %s", timeZone)
+		}
+		timeLocation = loc
+	}
+	return nil
+}
+
+// isTimeColumn return true for columns of timestamps: time, updateTime, createTimeStamp
+func isTimeColumn(column string) bool {
+	name := strings.ToLower(column)
+	return strings.Contains(name, "time") || strings.Contains(name, "date")
+}
+
+// formatCell format a cell of column in table output, epoch milliseconds
+// of time columns become times in the time zone and numbers are formatted
+// by formatNumber
+func formatCell(column, cell string) string {
+	if isTimeColumn(column) {
+		// only values in the range of epoch milliseconds since 2001
+		ms, err := strconv.ParseInt(cell, 10, 64)
+		if err == nil && ms >= 1e12 && ms < 1e14 {
+			return time.Unix(0, ms*int64(time.Millisecond)).In(timeLocation).Format("2006-01-02 15:04:05")
+		}
+		return cell
+	}
+	return formatNumber(column, cell)
+}
+
+// isQuantityColumn return false for columns of numbers which are not
+// quantities or valuations: ids and times
+func isQuantityColumn(column string) bool {
+	name := strings.ToLower(column)
+	return !strings.HasSuffix(name, "id") && !strings.HasSuffix(name, "_id") &&
+		!strings.Contains(name, "time") && !strings.Contains(name, "date")
+}
+
+// formatNumber format a numeric cell of column in table output by the
+// number format and decimal places, other cells are returned as is
+func formatNumber(column, cell string) string {
+	if numberFormat == numbersRaw && numberDecimals < 0 {
+		return cell
+	}
+	f, err := strconv.ParseFloat(cell, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) || !isQuantityColumn(column) {
+		return cell
+	}
+	if numberFormat == numbersShort {
+		for _, unit := range numberUnits[detectLang(lang)] {
+			if math.Abs(f) >= unit.size {
+				decimals := numberDecimals
+				if decimals < 0 {
+					decimals = 1
+				}
+				return strconv.FormatFloat(f/unit.size, 'f', decimals, 64) + unit.suffix
+			}
+		}
+	}
+	text := cell
+	if numberDecimals >= 0 {
+		text = strconv.FormatFloat(f, 'f', numberDecimals, 64)
+	} else if strings.Contains(text, ".") {
+		// Binance pads decimals with zeros: 0.10000000
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	if numberFormat == numbersRaw {
+		return text
+	}
+	return groupThousands(text)
+}
+
+// groupThousands insert thousands separators into the integer part of a
+// number: 1234567.89 to 1,234,567.89
+func groupThousands(number string) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") || strings.HasPrefix(number, "+") {
+		sign, number = number[:1], number[1:]
+	}
+	integer, fraction := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		integer, fraction = number[:i], number[i:]
+	}
+	var b strings.Builder
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + fraction
+}
+
+// displayWidth return the number of terminal columns of s, wide characters
+// like CJK take two
+func displayWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		if r >= 0x2E80 && r <= 0xFFDC {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,861 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// output formats of command results
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatYAML  = "yaml"
+	// one compact JSON result per line, for events of watch commands
+	formatNDJSON = "ndjson"
+	// primary identifiers only, one per line, set by -q
+	formatIDs = "ids"
+)
+
+// outputFormat is the format of command results, table on a terminal and
+// json otherwise if not set
+var outputFormat string
+
+// outputQuery is the query applied to results before printing
+var outputQuery string
+
+// outputSortBy is the field[:desc] lists of results are sorted by
+var outputSortBy string
+
+// outputFilters is the field op value filters all kept items of lists of
+// results match
+var outputFilters cli.StringSlice
+
+// resultTransforms transform results decoded by decodeOrdered before
+// printing, in order: filter, sort and query
+var resultTransforms []func(v interface{}) interface{}
+
+// compactOutput print JSON results on a single line
+var compactOutput bool
+
+// quietOutput print only primary identifiers of results
+var quietOutput bool
+
+// noColor disable colors of table output, colors are only used on a terminal
+var noColor bool
+
+// colorOutput is true if table output is colored
+var colorOutput bool
+
+// outputColumns is the comma separated columns of table and csv output
+var outputColumns string
+
+// commandName is the full name of the command run: futures positions
+var commandName string
+
+// defaultColumns define main columns in table output of commands with wide results
+var defaultColumns = map[string][]string{
+	"list-orders":         {"symbol", "orderId", "side", "type", "price", "origQty", "executedQty", "status", "time"},
+	"futures list-orders": {"symbol", "orderId", "side", "positionSide", "type", "price", "origQty", "executedQty", "status", "time"},
+	"order-history":       {"symbol", "orderId", "side", "type", "price", "origQty", "executedQty", "status", "time"},
+	"trade-history":       {"symbol", "id", "orderId", "price", "qty", "quoteQty", "commission", "commissionAsset", "isBuyer", "time"},
+	"ticker":              {"symbol", "lastPrice", "priceChangePercent", "highPrice", "lowPrice", "volume", "quoteVolume"},
+	"klines":              {"openTime", "open", "high", "low", "close", "volume", "indicators"},
+}
+
+// trackCommands set commandName when a command of commands is run
+func trackCommands(commands []cli.Command, parent string) {
+	for i := range commands {
+		cmd := &commands[i]
+		fullName := strings.TrimSpace(parent + " " + cmd.Name)
+		before := cmd.Before
+		cmd.Before = func(c *cli.Context) error {
+			commandName = fullName
+			if before != nil {
+				return before(c)
+			}
+			return nil
+		}
+		trackCommands(cmd.Subcommands, fullName)
+	}
+}
+
+// selectColumns return columns selected by --columns or the command
+// defaults in their order, a selected name also selects its nested columns:
+// indicators selects indicators.rsi. All columns are returned if none is selected.
+func selectColumns(columns []string) []string {
+	selected := SplitItems([]string{outputColumns})
+	if len(selected) == 0 && outputFormat == formatTable {
+		selected = defaultColumns[commandName]
+	}
+	var res []string
+	for _, s := range selected {
+		for _, column := range columns {
+			if strings.EqualFold(column, s) || strings.HasPrefix(strings.ToLower(column), strings.ToLower(s)+".") {
+				res = append(res, column)
+			}
+		}
+	}
+	if len(res) == 0 {
+		return columns
+	}
+	return res
+}
+
+// Renderer render a result decoded by decodeOrdered into b
+type Renderer func(b *strings.Builder, v interface{}) error
+
+// renderers of output formats, json is printed as encoded
+var renderers = map[string]Renderer{
+	formatTable: func(b *strings.Builder, v interface{}) error {
+		writeTables(b, "", v)
+		return nil
+	},
+	formatCSV: writeCSV,
+	formatYAML: func(b *strings.Builder, v interface{}) error {
+		writeYAML(b, v, "")
+		return nil
+	},
+	formatIDs: func(b *strings.Builder, v interface{}) error {
+		writeIDs(b, v, true)
+		return nil
+	},
+	formatNDJSON: func(b *strings.Builder, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		b.Write(data)
+		return nil
+	},
+}
+
+// initOutput check the output format and pick the default one if not set
+func initOutput() error {
+	resultTransforms = nil
+	if len(outputFilters) > 0 {
+		var filters []*rowFilter
+		for _, spec := range outputFilters {
+			f, err := parseFilter(spec)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			filters = append(filters, f)
+		}
+		resultTransforms = append(resultTransforms, func(v interface{}) interface{} {
+			return filterRows(v, filters)
+		})
+	}
+	if outputSortBy != "" {
+		s, err := parseSortBy(outputSortBy)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		resultTransforms = append(resultTransforms, s.Sort)
+	}
+	if outputQuery != "" {
+		query, err := parseQuery(outputQuery)
+		if err != nil {
+			return errors.Annotate(err, "invalid query")
+		}
+		resultTransforms = append(resultTransforms, query)
+	}
+	colorOutput = !noColor && os.Getenv("NO_COLOR") == "" && isTerminal()
+	if quietOutput {
+		outputFormat = formatIDs
+	}
+	if outputFormat == "" {
+		outputFormat = formatJSON
+		if isTerminal() {
+			outputFormat = formatTable
+		}
+	}
+	if outputFormat == formatTemplate || strings.HasPrefix(outputFormat, formatTemplate+"=") {
+		err := parseOutputTemplate(outputFormat)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		outputFormat = formatTemplate
+		return nil
+	}
+	if _, ok := renderers[outputFormat]; !ok && outputFormat != formatJSON {
+		return errors.Errorf("invalid output format: %s", outputFormat)
+	}
+	return nil
+}
+
+// print write a command result in the output format, all command results
+// go through it. The result is redacted first if --redact is set, then
+// transformed by --filter, --sort-by and --query.
+func print(ret interface{}) error {
+	if redactOutput {
+		var err error
+		ret, err = redact(ret)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	out, err := encodeResult(ret)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(resultTransforms) > 0 {
+		ret, out, err = transformResult(out)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	var text string
+	if outputFormat == formatTemplate {
+		text, err = renderTemplate(ret)
+	} else {
+		text, err = renderOutput(out)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if refresh != nil {
+		refresh.Draw(text)
+	} else {
+		fmt.Println(text)
+	}
+	resultSummary = summarize(out)
+	return nil
+}
+
+// orderedObject define a JSON object keeping the order of its keys, so
+// columns follow the field order of results
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{values: make(map[string]interface{})}
+}
+
+// Set set value of key, new keys are appended
+func (o *orderedObject) Set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON encode the object with keys in order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// decodeOrdered decode JSON into *orderedObject, []interface{},
+// json.Number, string, bool or nil values
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrderedValue(dec)
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		obj := newOrderedObject()
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			key, _ := tok.(string)
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			obj.Set(key, value)
+		}
+		_, err = dec.Token()
+		return obj, errors.Trace(err)
+	case '[':
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, errors.Trace(err)
+	}
+	return nil, errors.Errorf("unexpected delimiter: %s", delim)
+}
+
+// isScalar return true if v is not an object or a list
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case *orderedObject, []interface{}:
+		return false
+	}
+	return true
+}
+
+// allScalars return true if no item of list is an object or a list
+func allScalars(list []interface{}) bool {
+	for _, item := range list {
+		if !isScalar(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// isCell return true if v fits in a cell: a scalar or a list of scalars
+func isCell(v interface{}) bool {
+	list, ok := v.([]interface{})
+	return isScalar(v) || ok && allScalars(list)
+}
+
+// flattenObject flatten nested objects of obj into dotted keys: a.b
+func flattenObject(obj *orderedObject) *orderedObject {
+	flat := newOrderedObject()
+	for _, key := range obj.keys {
+		if child, ok := obj.values[key].(*orderedObject); ok {
+			child = flattenObject(child)
+			for _, k := range child.keys {
+				flat.Set(key+"."+k, child.values[k])
+			}
+			continue
+		}
+		flat.Set(key, obj.values[key])
+	}
+	return flat
+}
+
+// cellText return text of a value in a cell, lists of scalars are joined
+// with commas and other lists are encoded as JSON
+func cellText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		if !allScalars(v) {
+			data, _ := json.Marshal(v)
+			return string(data)
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = cellText(item)
+		}
+		return strings.Join(items, ",")
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// isNumeric return true if s is a number, Binance returns most numbers as strings
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// objectRows return columns and rows of a list of objects, nested objects
+// are flattened into dotted columns. False is returned if an item is not an object.
+func objectRows(list []interface{}) ([]string, [][]string, bool) {
+	var columns []string
+	seen := make(map[string]bool)
+	objects := make([]*orderedObject, len(list))
+	for i, item := range list {
+		obj, ok := item.(*orderedObject)
+		if !ok {
+			return nil, nil, false
+		}
+		objects[i] = flattenObject(obj)
+		for _, key := range objects[i].keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	columns = selectColumns(columns)
+	rows := make([][]string, len(objects))
+	for i, obj := range objects {
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			rows[i][j] = cellText(obj.values[column])
+		}
+	}
+	return columns, rows, true
+}
+
+// writeTable write rows aligned in columns under upper case or translated
+// headers, columns of numbers are right aligned
+func writeTable(b *strings.Builder, columns []string, rows [][]string) {
+	// cells of key/value tables are formatted by the key of the row
+	cellColumn := func(row []string, i int) string {
+		if len(columns) == 2 && columns[0] == "key" {
+			return row[0]
+		}
+		return columns[i]
+	}
+	texts := make([][]string, len(rows))
+	for r, row := range rows {
+		texts[r] = make([]string, len(row))
+		for i, cell := range row {
+			texts[r][i] = formatCell(cellColumn(row, i), cell)
+		}
+	}
+	headers := make([]string, len(columns))
+	widths := make([]int, len(columns))
+	numeric := make([]bool, len(columns))
+	for i, column := range columns {
+		headers[i] = columnHeader(column)
+		widths[i] = displayWidth(headers[i])
+		// times are left aligned like text
+		numeric[i] = !isTimeColumn(column)
+		for r, row := range rows {
+			if w := displayWidth(texts[r][i]); w > widths[i] {
+				widths[i] = w
+			}
+			if row[i] != "" && !isNumeric(row[i]) {
+				numeric[i] = false
+			}
+		}
+	}
+	writeRow := func(row, texts []string) {
+		var line strings.Builder
+		for i, cell := range texts {
+			pad := strings.Repeat(" ", widths[i]-displayWidth(cell))
+			if row != nil {
+				cell = colorCell(cellColumn(row, i), row[i], cell)
+			}
+			if numeric[i] {
+				cell = pad + cell
+			} else {
+				cell += pad
+			}
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	writeRow(nil, headers)
+	for r, row := range rows {
+		writeRow(row, texts[r])
+	}
+}
+
+// writeTables render v as tables, lists of objects become tables and
+// scalar fields of objects become key/value tables. Nested values are
+// written in sections titled with their dotted path.
+func writeTables(b *strings.Builder, title string, v interface{}) {
+	section := func() {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if title != "" {
+			b.WriteString(title + ":\n")
+		}
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			return
+		}
+		if columns, rows, ok := objectRows(v); ok {
+			section()
+			writeTable(b, columns, rows)
+			return
+		}
+		if allScalars(v) {
+			section()
+			for _, item := range v {
+				b.WriteString(cellText(item) + "\n")
+			}
+			return
+		}
+		for i, item := range v {
+			writeTables(b, joinPath(title, strconv.Itoa(i)), item)
+		}
+	case *orderedObject:
+		var rows [][]string
+		var nested []string
+		for _, key := range v.keys {
+			if isCell(v.values[key]) {
+				rows = append(rows, []string{key, cellText(v.values[key])})
+			} else {
+				nested = append(nested, key)
+			}
+		}
+		if len(rows) > 0 {
+			section()
+			writeTable(b, []string{"key", "value"}, rows)
+		}
+		for _, key := range nested {
+			writeTables(b, joinPath(title, key), v.values[key])
+		}
+	default:
+		section()
+		b.WriteString(cellText(v) + "\n")
+	}
+}
+
+// isFlat return true if obj flattens into cells only
+func isFlat(obj *orderedObject) bool {
+	for _, value := range obj.values {
+		if child, ok := value.(*orderedObject); ok {
+			if !isFlat(child) {
+				return false
+			}
+		} else if !isCell(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// flatRows flatten v into rows of a single table and add them with the
+// dotted path of the nested value they come from
+func flatRows(path string, v interface{}, add func(path string, row *orderedObject)) {
+	scalarRow := func(value interface{}) *orderedObject {
+		row := newOrderedObject()
+		row.Set("value", value)
+		return row
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		for i, item := range v {
+			switch item := item.(type) {
+			case *orderedObject:
+				if isFlat(item) {
+					add(path, flattenObject(item))
+				} else {
+					flatRows(joinPath(path, strconv.Itoa(i)), item, add)
+				}
+			case []interface{}:
+				flatRows(joinPath(path, strconv.Itoa(i)), item, add)
+			default:
+				add(path, scalarRow(item))
+			}
+		}
+	case *orderedObject:
+		if isFlat(v) {
+			add(path, flattenObject(v))
+			return
+		}
+		for _, key := range v.keys {
+			if isCell(v.values[key]) {
+				add(joinPath(path, key), scalarRow(v.values[key]))
+			} else {
+				flatRows(joinPath(path, key), v.values[key], add)
+			}
+		}
+	default:
+		add(path, scalarRow(v))
+	}
+}
+
+// writeCSV render v as CSV with a header row, nested values are merged into
+// one table with a key column holding their dotted path. Columns follow the
+// field order of results.
+func writeCSV(b *strings.Builder, v interface{}) error {
+	var paths []string
+	var rows []interface{}
+	grouped := false
+	flatRows("", v, func(path string, row *orderedObject) {
+		paths = append(paths, path)
+		rows = append(rows, row)
+		if path != "" {
+			grouped = true
+		}
+	})
+	columns, cells, _ := objectRows(rows)
+	w := csv.NewWriter(b)
+	if grouped {
+		columns = append([]string{"key"}, columns...)
+	}
+	err := w.Write(columns)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i, row := range cells {
+		if grouped {
+			row = append([]string{paths[i]}, row...)
+		}
+		err = w.Write(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	w.Flush()
+	return errors.Trace(w.Error())
+}
+
+// yamlPlainWord return true if s can be written unquoted: it starts with a
+// letter and has only letters, digits and _ - . / and inner spaces, so no
+// YAML 1.1 resolver reads it as a number, timestamp or special float
+func yamlPlainWord(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	for i, r := range s {
+		letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if i == 0 && !letter {
+			return false
+		}
+		if !letter && !(r >= '0' && r <= '9') && !strings.ContainsRune("_-./ ", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// yamlScalar return v as a YAML scalar, strings are quoted unless they are
+// plain words not read as booleans or null
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		plain := yamlPlainWord(v)
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+			plain = false
+		}
+		if plain {
+			return v
+		}
+		// a JSON string is a valid double-quoted YAML scalar
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return cellText(v)
+}
+
+// writeYAML write v as a YAML block at indent
+func writeYAML(b *strings.Builder, v interface{}, indent string) {
+	switch v := v.(type) {
+	case *orderedObject:
+		for _, key := range v.keys {
+			b.WriteString(indent + yamlScalar(key) + ":")
+			writeYAMLValue(b, v.values[key], indent)
+		}
+	case []interface{}:
+		for _, item := range v {
+			b.WriteString(indent + "-")
+			if obj, ok := item.(*orderedObject); ok && len(obj.keys) > 0 {
+				// the first key goes on the line of the dash
+				var item strings.Builder
+				writeYAML(&item, obj, indent+"  ")
+				b.WriteString(" " + strings.TrimPrefix(item.String(), indent+"  "))
+				continue
+			}
+			writeYAMLValue(b, item, indent)
+		}
+	default:
+		b.WriteString(indent + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLValue write v after a key or a dash, collections start on the
+// next line indented
+func writeYAMLValue(b *strings.Builder, v interface{}, indent string) {
+	switch v := v.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent+"  ")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent+"  ")
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+// joinPath join keys of nested values with dots
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// encodeResult encode a result as indented JSON, or on a single line if
+// --compact is set
+func encodeResult(v interface{}) ([]byte, error) {
+	if compactOutput {
+		out, err := json.Marshal(v)
+		return out, errors.Trace(err)
+	}
+	out, err := json.MarshalIndent(v, "", "    ")
+	return out, errors.Trace(err)
+}
+
+// transformResult apply result transforms to a result encoded as JSON, the
+// transformed result is returned decoded with JSON keys as fields and encoded
+func transformResult(data []byte) (interface{}, []byte, error) {
+	v, err := decodeOrdered(data)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	for _, transform := range resultTransforms {
+		v = transform(v)
+	}
+	out, err := encodeResult(v)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	var ret interface{}
+	err = json.Unmarshal(out, &ret)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return ret, out, nil
+}
+
+// renderOutput render a result encoded as JSON in the output format
+func renderOutput(data []byte) (string, error) {
+	if outputFormat == formatJSON {
+		return string(data), nil
+	}
+	v, err := decodeOrdered(data)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var b strings.Builder
+	err = renderers[outputFormat](&b, v)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// ANSI foreground colors of table cells, the reset keeps other styles like
+// highlights of refresh mode
+const (
+	colorGreen  = "\033[32m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[39m"
+)
+
+// colorCell color text of a cell of column by its raw value if colors are
+// enabled: price changes and profits green or red by sign, sides green for
+// BUY and red for SELL, partially filled statuses yellow
+func colorCell(column, cell, text string) string {
+	if !colorOutput || cell == "" {
+		return text
+	}
+	var color string
+	name := strings.ToLower(column)
+	switch {
+	case name == "side":
+		switch strings.ToUpper(cell) {
+		case "BUY":
+			color = colorGreen
+		case "SELL":
+			color = colorRed
+		}
+	case name == "status" || strings.HasSuffix(name, ".status"):
+		if strings.ToUpper(cell) == "PARTIALLY_FILLED" {
+			color = colorYellow
+		}
+	case strings.Contains(name, "change") || strings.Contains(name, "pnl") || strings.Contains(name, "profit"):
+		switch v := StrToFloat(cell); {
+		case v > 0:
+			color = colorGreen
+		case v < 0:
+			color = colorRed
+		}
+	}
+	if color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// idKeys are keys of primary identifiers of results, by priority
+var idKeys = []string{"orderId", "order_id", "tranId", "tran_id", "txId", "id", "planId", "email", "symbol", "asset"}
+
+// writeIDs write the primary identifier of each object in v one per line,
+// objects without one are searched for nested objects. Scalars of lists
+// and of the top level object, results of accounts like transfer ids, are
+// written too, errors of accounts are skipped.
+func writeIDs(b *strings.Builder, v interface{}, top bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			writeIDs(b, item, false)
+		}
+	case *orderedObject:
+		for _, key := range idKeys {
+			if id, ok := lookupField(v, key); ok && isScalar(id) {
+				b.WriteString(cellText(id) + "\n")
+				return
+			}
+		}
+		for _, key := range v.keys {
+			if top || !isScalar(v.values[key]) {
+				writeIDs(b, v.values[key], false)
+			}
+		}
+	default:
+		text := cellText(v)
+		if text != "" && !strings.HasPrefix(text, T("error")+":") {
+			b.WriteString(text + "\n")
+		}
+	}
+}
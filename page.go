@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// Page define the time range and paging of history commands
+type Page struct {
+	StartTime int64
+	EndTime   int64
+	// Limit is the max number of records of each account, 0 for all
+	Limit int
+	// FromID is the first id of records if not 0, sent to endpoints
+	// accepting it and applied to records of the others
+	FromID int64
+	// All fetch the whole time range in windows accepted by the API,
+	// instead of a single request with defaults of the API
+	All bool
+}
+
+// Windows return time windows of at most span covering the time range if
+// All is set, ending now by default, or the time range as is
+func (p *Page) Windows(span time.Duration) ([][2]int64, error) {
+	if !p.All {
+		return [][2]int64{{p.StartTime, p.EndTime}}, nil
+	}
+	if p.StartTime == 0 {
+		return nil, errors.New("start time required to fetch all pages: --start-time 90d")
+	}
+	endTime := p.EndTime
+	if endTime == 0 {
+		endTime = MilliTime(time.Now())
+	}
+	if p.StartTime >= endTime {
+		return nil, errors.New("start time must be before end time")
+	}
+	window := int64(span / time.Millisecond)
+	var windows [][2]int64
+	for start := p.StartTime; start < endTime; start += window {
+		end := start + window - 1
+		if end > endTime {
+			end = endTime
+		}
+		windows = append(windows, [2]int64{start, end})
+	}
+	return windows, nil
+}
+
+// Fetch call fetch with each window of Windows in order
+func (p *Page) Fetch(span time.Duration, fetch func(startTime, endTime int64) error) error {
+	windows, err := p.Windows(span)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var progress *Progress
+	if len(windows) > 1 {
+		progress = startProgress("pages", len(windows))
+		defer progress.Done()
+	}
+	for _, w := range windows {
+		err = fetch(w[0], w[1])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		progress.Add(1)
+	}
+	return nil
+}
+
+// recordID return the primary id of a record: a struct or pointer to
+// struct with a field tagged by one of idKeys
+func recordID(v reflect.Value) (int64, bool) {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+	for _, key := range idKeys {
+		for i := 0; i < v.NumField(); i++ {
+			tag := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if tag != key {
+				continue
+			}
+			switch f := v.Field(i); f.Kind() {
+			case reflect.Int, reflect.Int32, reflect.Int64:
+				return f.Int(), true
+			case reflect.String:
+				id, err := strconv.ParseInt(f.String(), 10, 64)
+				return id, err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+// Apply return records of list, a slice, with ids from FromID and at most
+// Limit of them. Records without an id are kept.
+func (p *Page) Apply(list interface{}) interface{} {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice || (p.FromID == 0 && p.Limit <= 0) {
+		return list
+	}
+	res := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if p.Limit > 0 && res.Len() >= p.Limit {
+			break
+		}
+		if id, ok := recordID(v.Index(i)); ok && id < p.FromID {
+			continue
+		}
+		res = reflect.Append(res, v.Index(i))
+	}
+	return res.Interface()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// PayParty define payer or receiver of a Binance Pay transaction
+type PayParty struct {
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	BinanceID json.Number `json:"binanceId,omitempty"`
+	AccountID json.Number `json:"accountId,omitempty"`
+	Email     string      `json:"email,omitempty"`
+}
+
+// PayTransaction define a Binance Pay transaction
+type PayTransaction struct {
+	OrderType       string   `json:"orderType"`
+	TransactionID   string   `json:"transactionId"`
+	TransactionTime int64    `json:"transactionTime"`
+	Amount          string   `json:"amount"`
+	Currency        string   `json:"currency"`
+	WalletType      int      `json:"walletType"`
+	PayerInfo       PayParty `json:"payerInfo"`
+	ReceiverInfo    PayParty `json:"receiverInfo"`
+	// Counterparty is the configured account on the other side, if any
+	Counterparty string `json:"counterparty,omitempty"`
+}
+
+// ListPayTransactions list Binance Pay transactions between startTime and endTime
+func (account *Account) ListPayTransactions(startTime, endTime int64) ([]*PayTransaction, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	setTimeRange(params, 100, startTime, endTime)
+	res := new(struct {
+		Data []*PayTransaction `json:"data"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/pay/transactions", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res.Data, nil
+}
+
+// GetUID get user id of account, the Binance ID of Pay transactions
+func (account *Account) GetUID() (string, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		UID json.Number `json:"uid"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/api/v3/account", nil, true, res)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return res.UID.String(), nil
+}
+
+// payHistory define Binance Pay transactions of an account
+type payHistory struct {
+	uid          string
+	transactions []*PayTransaction
+}
+
+// payHistorySpan is the max time range of a request of Binance Pay history
+const payHistorySpan = 90 * 24 * time.Hour
+
+// listPayTransactions list Binance Pay transactions of accounts, transactions
+// between configured accounts are marked with the account on the other side
+func listPayTransactions(page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		uid, err := account.GetUID()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var transactions []*PayTransaction
+		err = page.Fetch(payHistorySpan, func(startTime, endTime int64) error {
+			res, err := account.ListPayTransactions(startTime, endTime)
+			transactions = append(transactions, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sort.Slice(transactions, func(i, j int) bool {
+			return transactions[i].TransactionTime < transactions[j].TransactionTime
+		})
+		transactions = page.Apply(transactions).([]*PayTransaction)
+		return &payHistory{uid: uid, transactions: transactions}, nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		names := make(map[string]string)
+		for name, res := range results {
+			if history, ok := res.(*payHistory); ok && history.uid != "" {
+				names[history.uid] = name
+			}
+		}
+		for name, res := range results {
+			history, ok := res.(*payHistory)
+			if !ok {
+				continue
+			}
+			for _, t := range history.transactions {
+				for _, id := range []json.Number{t.PayerInfo.BinanceID, t.ReceiverInfo.BinanceID} {
+					if other, ok := names[id.String()]; ok && other != name {
+						t.Counterparty = other
+					}
+				}
+			}
+			results[name] = history.transactions
+		}
+		return results, nil
+	})
+}
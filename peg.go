@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// supported peg references of order price
+const (
+	pegMid = "mid"
+	pegBid = "bid"
+	pegAsk = "ask"
+)
+
+// parseOffset parse price offset: 0.5 absolute or 5bps relative, can be negative
+func parseOffset(offset string) (value float64, bps bool, err error) {
+	offset = strings.TrimSpace(strings.ToLower(offset))
+	if offset == "" {
+		return 0, false, nil
+	}
+	if strings.HasSuffix(offset, "bps") {
+		offset = strings.TrimSuffix(offset, "bps")
+		bps = true
+	}
+	value, err = strconv.ParseFloat(offset, 64)
+	if err != nil {
+		return 0, false, errors.Errorf("invalid offset: %s", offset)
+	}
+	return value, bps, nil
+}
+
+// tickSize return tick size of PRICE_FILTER of symbol, 0 if not found
+func tickSize(filters []map[string]interface{}) float64 {
+	for _, filter := range filters {
+		if filter["filterType"] == "PRICE_FILTER" {
+			if s, ok := filter["tickSize"].(string); ok {
+				return StrToFloat(s)
+			}
+		}
+	}
+	return 0
+}
+
+// roundToTick round price to tick, down for buy and up for sell orders, so
+// that the order is never more aggressive than the unrounded price: a buy
+// is never above it and a sell never below it
+func roundToTick(price, tick float64, side string) string {
+	if tick <= 0 {
+		return strconv.FormatFloat(price, 'f', -1, 64)
+	}
+	ticks := price / tick
+	if strings.ToUpper(side) == "SELL" {
+		ticks = math.Ceil(ticks - 1e-9)
+	} else {
+		ticks = math.Floor(ticks + 1e-9)
+	}
+	decimals := 0
+	tickStr := strconv.FormatFloat(tick, 'f', -1, 64)
+	if i := strings.IndexByte(tickStr, '.'); i >= 0 {
+		decimals = len(tickStr) - i - 1
+	}
+	return strconv.FormatFloat(ticks*tick, 'f', decimals, 64)
+}
+
+// pegPrice resolve price of order pegged to mid, bid or ask of the live book
+// of symbol with offset, positive offset raises the price
+func (account *Account) pegPrice(symbol, side, peg, offset string) (string, error) {
+	value, bps, err := parseOffset(offset)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	ticker, err := account.GetBookTicker(symbol)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	bid, ask := StrToFloat(ticker.BidPrice), StrToFloat(ticker.AskPrice)
+	var price float64
+	switch strings.ToLower(peg) {
+	case pegMid:
+		price = (bid + ask) / 2
+	case pegBid:
+		price = bid
+	case pegAsk:
+		price = ask
+	default:
+		return "", errors.Errorf("invalid peg: %s", peg)
+	}
+	if price <= 0 {
+		return "", errors.Errorf("empty book of %s", symbol)
+	}
+	if bps {
+		price *= 1 + value/10000
+	} else {
+		price += value
+	}
+	if price <= 0 {
+		return "", errors.Errorf("invalid pegged price: %f", price)
+	}
+	symbols, err := account.GetSymbols([]string{strings.ToUpper(symbol)})
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return roundToTick(price, tickSize(symbols[strings.ToUpper(symbol)].Filters), side), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/juju/errors"
+)
+
+// checkPermissions refuse to use a secret file accessible by group or others,
+// like ssh does for private keys. Only a warning is logged with
+// --insecure-permissions.
+func checkPermissions(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	perm := info.Mode().Perm()
+	if perm&0077 == 0 {
+		return nil
+	}
+	msg := T("insecure_permissions", perm, path)
+	if insecurePermissions {
+		log.Print("WARNING: ", msg)
+		return nil
+	}
+	return errors.New(msg)
+}
+
+// fixPermissions restrict keyfile and local state to the current user
+func fixPermissions() error {
+	fixed := make(map[string]string)
+	chmod := func(path string, mode os.FileMode) error {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if info.Mode().Perm() == mode {
+			return nil
+		}
+		err = os.Chmod(path, mode)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		fixed[path] = mode.String()
+		return nil
+	}
+	err := chmod(keyfile, 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = chmod(dataDir, 0700)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return chmod(path, 0700)
+		}
+		return chmod(path, 0600)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return print(fixed)
+}
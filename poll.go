@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// publicAccount return an account without keys for public endpoints
+func publicAccount() *Account {
+	client := binance.NewClient("", "")
+	client.Debug = debug
+	return &Account{Client: client, Name: "public"}
+}
+
+// pollStream fetch the REST equivalent of a market stream like
+// bnbbtc@ticker, bnbbtc@bookTicker, bnbbtc@depth5, bnbbtc@kline_1m,
+// bnbbtc@trade or bnbbtc@aggTrade
+func (account *Account) pollStream(stream string) (interface{}, error) {
+	parts := strings.SplitN(stream, "@", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid stream: %s", stream)
+	}
+	symbol, kind := strings.ToUpper(parts[0]), parts[1]
+	ctx, cancel := newContext()
+	defer cancel()
+	switch {
+	case kind == "ticker" || kind == "miniTicker":
+		stats, err := account.NewListPriceChangeStatsService().Symbol(symbol).Do(ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return stats, nil
+	case kind == "bookTicker":
+		ticker, err := account.GetBookTicker(symbol)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return ticker, nil
+	case strings.HasPrefix(kind, "depth"):
+		levels := strings.SplitN(strings.TrimPrefix(kind, "depth"), "@", 2)[0]
+		limit, err := strconv.Atoi(levels)
+		if err != nil {
+			limit = 100
+		}
+		depth, err := account.NewDepthService().Symbol(symbol).Limit(limit).Do(ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return depth, nil
+	case strings.HasPrefix(kind, "kline_"):
+		klines, err := account.ListKlines(symbol, strings.TrimPrefix(kind, "kline_"), 1, 0, 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return klines, nil
+	case kind == "trade" || kind == "aggTrade":
+		trades, err := account.NewRecentTradesService().Symbol(symbol).Limit(10).Do(ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return trades, nil
+	}
+	return nil, errors.Errorf("stream not supported by polling: %s", stream)
+}
+
+// pollStreams poll REST equivalents of streams every interval and handle
+// the results with handler, used when websocket connections are blocked.
+// Errors of the first round are returned, later ones are only logged.
+func pollStreams(streams []string, interval time.Duration, handler func(stream string, data interface{})) error {
+	log.Printf("WARNING: polling REST API every %s, data may be less fresh than websocket streams", interval)
+	account := publicAccount()
+	for first := true; ; first = false {
+		for _, stream := range streams {
+			data, err := account.pollStream(stream)
+			if err != nil && first {
+				return errors.Trace(err)
+			}
+			if err != nil {
+				log.Print("failed to poll stream: ", err)
+				continue
+			}
+			handler(stream, data)
+		}
+		time.Sleep(interval)
+	}
+}
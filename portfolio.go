@@ -0,0 +1,204 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// PortfolioAsset define amount of an asset summed over wallets with its value in quote asset
+type PortfolioAsset struct {
+	Asset   string             `json:"asset"`
+	Amount  float64            `json:"amount"`
+	Wallets map[string]float64 `json:"wallets"`
+	Value   *float64           `json:"value,omitempty"`
+}
+
+// AccountPortfolio define assets of an account valued in quote asset,
+// assets without a price route to quote asset are not in total
+type AccountPortfolio struct {
+	Assets   []*PortfolioAsset `json:"assets"`
+	Total    float64           `json:"total"`
+	Unpriced []string          `json:"unpriced,omitempty"`
+}
+
+// Portfolio define portfolios of accounts with subtotals and aggregate of all accounts
+type Portfolio struct {
+	Quote     string                 `json:"quote"`
+	Accounts  map[string]interface{} `json:"accounts"`
+	Subtotals map[string]float64     `json:"subtotals"`
+	Assets    []*PortfolioAsset      `json:"assets"`
+	Total     float64                `json:"total"`
+}
+
+// walletAmounts get amounts of assets in wallet: spot, funding, earn (Simple
+// Earn positions), margin (net of debt) or futures (including unrealized
+// pnl). Liquid staking tokens like WBETH and BNSOL are held in the spot wallet.
+func (account *Account) walletAmounts(wallet string) (map[string]float64, error) {
+	amounts := make(map[string]float64)
+	switch wallet {
+	case "spot":
+		err := account.UpdateBalances(nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, balance := range account.Balances {
+			amounts[balance.Asset] += StrToFloat(balance.Free) + StrToFloat(balance.Locked)
+		}
+	case "funding":
+		balances, err := account.ListFundingBalances()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, b := range balances {
+			amounts[b.Asset] += StrToFloat(b.Free) + StrToFloat(b.Locked) +
+				StrToFloat(b.Freeze) + StrToFloat(b.Withdrawing)
+		}
+	case "margin":
+		margin, err := account.GetMarginAccount()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, a := range margin.UserAssets {
+			amounts[a.Asset] += StrToFloat(a.NetAsset)
+		}
+	case "earn":
+		flexible, err := account.ListFlexibleEarn("")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, p := range flexible {
+			amounts[p.Asset] += StrToFloat(p.TotalAmount)
+		}
+		locked, err := account.ListLockedEarn("")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, p := range locked {
+			amounts[p.Asset] += StrToFloat(p.Amount)
+		}
+	case "futures":
+		balances, err := account.ListFuturesBalances()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, b := range balances {
+			amounts[b.Asset] += StrToFloat(b.Balance) + StrToFloat(b.CrossUnPnl)
+		}
+	default:
+		return nil, errors.Errorf("invalid wallet: %s", wallet)
+	}
+	return amounts, nil
+}
+
+// buildPortfolio value amounts of assets by wallet in quote asset, sorted by value
+func buildPortfolio(amounts map[string]map[string]float64, graph *PriceGraph, quote string) *AccountPortfolio {
+	portfolio := new(AccountPortfolio)
+	for asset, wallets := range amounts {
+		item := &PortfolioAsset{Asset: asset, Wallets: wallets}
+		for _, amount := range wallets {
+			item.Amount += amount
+		}
+		if item.Amount == 0 {
+			continue
+		}
+		if value, err := graph.Convert(item.Amount, asset, quote); err == nil {
+			value = roundTotal(value, quote)
+			item.Value = &value
+			portfolio.Total += value
+		} else {
+			portfolio.Unpriced = append(portfolio.Unpriced, asset)
+		}
+		portfolio.Assets = append(portfolio.Assets, item)
+	}
+	sort.Slice(portfolio.Assets, func(i, j int) bool {
+		a, b := portfolio.Assets[i], portfolio.Assets[j]
+		if (a.Value == nil) != (b.Value == nil) {
+			return a.Value != nil
+		}
+		if a.Value != nil && *a.Value != *b.Value {
+			return *a.Value > *b.Value
+		}
+		return a.Asset < b.Asset
+	})
+	sort.Strings(portfolio.Unpriced)
+	portfolio.Total = roundTotal(portfolio.Total, quote)
+	return portfolio
+}
+
+// showPortfolio value all balances of wallets of accounts in quote asset
+func showPortfolio(wallets []string, quote string) error {
+	return valuePortfolio(wallets, quote, nil)
+}
+
+// valuePortfolio value all balances of wallets of accounts in quote asset,
+// done is called with the portfolio before it is printed if not nil
+func valuePortfolio(wallets []string, quote string, done func(*Portfolio) error) error {
+	if len(wallets) == 0 {
+		wallets = []string{"spot", "funding"}
+	}
+	quote = strings.ToUpper(quote)
+	var graph *PriceGraph
+	return accountsDo(func(account *Account) (interface{}, error) {
+		if graph == nil {
+			var err error
+			graph, err = account.NewPriceGraph()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		amounts := make(map[string]map[string]float64)
+		for _, wallet := range wallets {
+			wallet = strings.ToLower(wallet)
+			res, err := account.walletAmounts(wallet)
+			if err != nil {
+				return nil, errors.Annotatef(err, "wallet %s", wallet)
+			}
+			for asset, amount := range res {
+				if amount == 0 {
+					continue
+				}
+				if amounts[asset] == nil {
+					amounts[asset] = make(map[string]float64)
+				}
+				amounts[asset][wallet] += amount
+			}
+		}
+		return buildPortfolio(amounts, graph, quote), nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		portfolio := &Portfolio{
+			Quote:     quote,
+			Accounts:  results,
+			Subtotals: make(map[string]float64),
+		}
+		amounts := make(map[string]map[string]float64)
+		for name, res := range results {
+			p, ok := res.(*AccountPortfolio)
+			if !ok {
+				continue
+			}
+			portfolio.Subtotals[name] = p.Total
+			for _, item := range p.Assets {
+				if amounts[item.Asset] == nil {
+					amounts[item.Asset] = make(map[string]float64)
+				}
+				for wallet, amount := range item.Wallets {
+					amounts[item.Asset][wallet] += amount
+				}
+			}
+		}
+		if graph != nil {
+			all := buildPortfolio(amounts, graph, quote)
+			portfolio.Assets = all.Assets
+			portfolio.Total = all.Total
+		}
+		if done != nil {
+			err := done(portfolio)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return portfolio, nil
+	})
+}
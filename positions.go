@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+const positionsFile = "positions.json"
+
+// Position define net inventory and average cost of a spot symbol
+type Position struct {
+	Symbol      string  `json:"symbol"`
+	Quantity    float64 `json:"quantity"`
+	AvgCost     float64 `json:"avg_cost"`
+	Cost        float64 `json:"cost"`
+	RealizedPnL float64 `json:"realized_pnl"`
+	Trades      int     `json:"trades"`
+	LastTradeID int64   `json:"last_trade_id"`
+	UpdateTime  int64   `json:"update_time"`
+}
+
+// Apply update position with trade using average cost method, return
+// realized pnl and matched quantity of a sell. Sold quantity beyond the
+// inventory (e.g. deposited coins) has no known cost and is not matched.
+func (pos *Position) Apply(trade *binance.TradeV3, baseAsset string) (pnl, matched float64) {
+	price := StrToFloat(trade.Price)
+	qty := StrToFloat(trade.Quantity)
+	quoteQty := StrToFloat(trade.QuoteQuantity)
+	if quoteQty == 0 {
+		quoteQty = price * qty
+	}
+	pos.Trades++
+	pos.LastTradeID = trade.ID
+	pos.UpdateTime = trade.Time
+	defer func() {
+		pos.AvgCost = 0
+		if pos.Quantity > 0 {
+			pos.AvgCost = pos.Cost / pos.Quantity
+		}
+	}()
+	if trade.IsBuyer {
+		if trade.CommissionAsset == baseAsset {
+			qty -= StrToFloat(trade.Commission)
+		}
+		pos.Quantity += qty
+		pos.Cost += quoteQty
+		return 0, 0
+	}
+	matched = qty
+	if matched > pos.Quantity {
+		matched = pos.Quantity
+	}
+	if matched <= 0 {
+		return 0, 0
+	}
+	avgCost := pos.Cost / pos.Quantity
+	pnl = (price - avgCost) * matched
+	pos.Cost -= avgCost * matched
+	pos.Quantity -= matched
+	pos.RealizedPnL += pnl
+	return pnl, matched
+}
+
+// loadPositions load positions of all accounts saved by bootstrap
+func loadPositions() (map[string]map[string]*Position, error) {
+	positions := make(map[string]map[string]*Position)
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, positionsFile))
+	if os.IsNotExist(err) {
+		return positions, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = json.Unmarshal(data, &positions)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return positions, nil
+}
+
+func savePositions(positions map[string]map[string]*Position) error {
+	err := os.MkdirAll(dataDir, 0700)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := json.MarshalIndent(positions, "", "    ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(ioutil.WriteFile(filepath.Join(dataDir, positionsFile), data, 0600))
+}
+
+// bootstrapPositions derive open positions of symbols from full trade
+// history and save them as the starting point of pnl tracking
+func bootstrapPositions(symbols []string) error {
+	positions, err := loadPositions()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var symbolInfo map[string]binance.Symbol
+	return accountsDo(func(account *Account) (interface{}, error) {
+		symbols := account.symbolsOr(symbols)
+		if len(symbols) == 0 {
+			return nil, errors.New("symbols required")
+		}
+		if symbolInfo == nil {
+			symbolInfo, err = account.GetSymbols(nil)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		res := make(map[string]*Position)
+		for _, symbol := range symbols {
+			trades, err := account.ListAllTrades(symbol)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			sort.Slice(trades, func(i, j int) bool {
+				return trades[i].ID < trades[j].ID
+			})
+			pos := &Position{Symbol: symbol}
+			for _, trade := range trades {
+				pos.Apply(trade, symbolInfo[symbol].BaseAsset)
+			}
+			res[symbol] = pos
+		}
+		return res, nil
+	}, func(results map[string]interface{}) (interface{}, error) {
+		for name, res := range results {
+			accountPositions, ok := res.(map[string]*Position)
+			if !ok {
+				continue
+			}
+			if positions[name] == nil {
+				positions[name] = make(map[string]*Position)
+			}
+			for symbol, pos := range accountPositions {
+				positions[name][symbol] = pos
+			}
+		}
+		err := savePositions(positions)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return results, nil
+	})
+}
+
+func showPositions() error {
+	positions, err := loadPositions()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if name != "" {
+		return print(map[string]map[string]*Position{name: positions[name]})
+	}
+	return print(positions)
+}
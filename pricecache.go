@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance"
+)
+
+// priceCacheTTL is how long fetched prices are reused, 0 to disable the cache
+var priceCacheTTL time.Duration
+
+// priceCache is a read-through cache of latest prices shared by all accounts,
+// prices are public so one fetch serves every account of an invocation
+var priceCache = struct {
+	sync.Mutex
+	all     []*binance.SymbolPrice
+	allTime time.Time
+	symbols map[string]*binance.SymbolPrice
+	times   map[string]time.Time
+}{
+	symbols: make(map[string]*binance.SymbolPrice),
+	times:   make(map[string]time.Time),
+}
+
+// cachedPrices return cached prices of symbol, or all symbols if empty. The
+// slice is a copy so callers may sort or filter it.
+func cachedPrices(symbol string) ([]*binance.SymbolPrice, bool) {
+	if priceCacheTTL <= 0 {
+		return nil, false
+	}
+	priceCache.Lock()
+	defer priceCache.Unlock()
+	fresh := time.Since(priceCache.allTime) < priceCacheTTL
+	if symbol == "" {
+		if !fresh || priceCache.all == nil {
+			return nil, false
+		}
+		return append([]*binance.SymbolPrice(nil), priceCache.all...), true
+	}
+	if t, ok := priceCache.times[symbol]; ok && time.Since(t) < priceCacheTTL {
+		return []*binance.SymbolPrice{priceCache.symbols[symbol]}, true
+	}
+	if fresh {
+		for _, p := range priceCache.all {
+			if p.Symbol == symbol {
+				return []*binance.SymbolPrice{p}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// cachePrices save fetched prices of symbol, or all symbols if empty
+func cachePrices(symbol string, prices []*binance.SymbolPrice) {
+	if priceCacheTTL <= 0 {
+		return
+	}
+	priceCache.Lock()
+	defer priceCache.Unlock()
+	now := time.Now()
+	if symbol == "" {
+		priceCache.all = append([]*binance.SymbolPrice(nil), prices...)
+		priceCache.allTime = now
+		return
+	}
+	for _, p := range prices {
+		priceCache.symbols[p.Symbol] = p
+		priceCache.times[p.Symbol] = now
+	}
+}
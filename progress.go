@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// progressDelay is how long tasks run before progress is shown, so
+	// quick commands don't flicker
+	progressDelay = 500 * time.Millisecond
+	// progressInterval is the interval of redrawing progress
+	progressInterval = 100 * time.Millisecond
+)
+
+// spinnerFrames are frames of the progress spinner
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// progressLine show progress of nested tasks on the last line of stderr
+var progressLine = struct {
+	sync.Mutex
+	tasks   []*Progress
+	frame   int
+	shown   bool
+	paused  int
+	stopC   chan struct{}
+	stopped chan struct{}
+}{}
+
+// Progress define a task with done steps of total, total is 0 if unknown
+type Progress struct {
+	label string
+	done  int
+	total int
+	start time.Time
+}
+
+// startProgress start showing progress of a task on stderr, after the
+// tasks already shown. Nil is returned if stdout is not a terminal, methods
+// of a nil Progress do nothing.
+func startProgress(label string, total int) *Progress {
+	if !isTerminal() {
+		return nil
+	}
+	p := &Progress{label: label, total: total, start: time.Now()}
+	progressLine.Lock()
+	defer progressLine.Unlock()
+	progressLine.tasks = append(progressLine.tasks, p)
+	if len(progressLine.tasks) == 1 {
+		progressLine.stopC = make(chan struct{})
+		progressLine.stopped = make(chan struct{})
+		log.SetOutput(progressLog{})
+		go drawProgress(progressLine.stopC, progressLine.stopped)
+	}
+	return p
+}
+
+// Add mark n more steps of the task done
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	progressLine.Lock()
+	defer progressLine.Unlock()
+	p.done += n
+}
+
+// Done stop showing the task, the progress line is cleared after the last task
+func (p *Progress) Done() {
+	if p == nil {
+		return
+	}
+	progressLine.Lock()
+	for i, task := range progressLine.tasks {
+		if task == p {
+			progressLine.tasks = append(progressLine.tasks[:i], progressLine.tasks[i+1:]...)
+			break
+		}
+	}
+	last := len(progressLine.tasks) == 0
+	stopC, stopped := progressLine.stopC, progressLine.stopped
+	progressLine.Unlock()
+	if last {
+		close(stopC)
+		<-stopped
+		log.SetOutput(os.Stderr)
+	}
+}
+
+// String return the label with done steps: trades 3000, accounts 2/5
+func (p *Progress) String() string {
+	if p.total > 0 {
+		return fmt.Sprintf("%s %d/%d", p.label, p.done, p.total)
+	}
+	return fmt.Sprintf("%s %d", p.label, p.done)
+}
+
+// clearProgress clear the progress line, the caller must hold the lock
+func clearProgress() {
+	if progressLine.shown {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		progressLine.shown = false
+	}
+}
+
+// redrawProgress draw tasks running longer than progressDelay unless
+// paused, the caller must hold the lock
+func redrawProgress() {
+	if progressLine.paused > 0 {
+		return
+	}
+	var items []string
+	for _, task := range progressLine.tasks {
+		if time.Since(task.start) >= progressDelay {
+			items = append(items, task.String())
+		}
+	}
+	if len(items) == 0 {
+		return
+	}
+	frame := spinnerFrames[progressLine.frame%len(spinnerFrames)]
+	fmt.Fprintf(os.Stderr, "\r\033[K%s %s", frame, strings.Join(items, " · "))
+	progressLine.shown = true
+}
+
+// pauseProgress hide the progress line until resume is called, while
+// prompting for input
+func pauseProgress() (resume func()) {
+	progressLine.Lock()
+	defer progressLine.Unlock()
+	progressLine.paused++
+	clearProgress()
+	return func() {
+		progressLine.Lock()
+		defer progressLine.Unlock()
+		progressLine.paused--
+	}
+}
+
+// drawProgress redraw the progress line until stopC is closed
+func drawProgress(stopC, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopC:
+			progressLine.Lock()
+			clearProgress()
+			progressLine.Unlock()
+			return
+		case <-ticker.C:
+			progressLine.Lock()
+			progressLine.frame++
+			redrawProgress()
+			progressLine.Unlock()
+		}
+	}
+}
+
+// progressLog write logs above the progress line while it is shown
+type progressLog struct{}
+
+func (progressLog) Write(data []byte) (int, error) {
+	progressLine.Lock()
+	defer progressLine.Unlock()
+	shown := progressLine.shown
+	clearProgress()
+	n, err := os.Stderr.Write(data)
+	if shown {
+		redrawProgress()
+	}
+	return n, err
+}
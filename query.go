@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/juju/errors"
+)
+
+// A subset of JMESPath applied to results before printing by --query:
+// fields a.b, quoted fields "a-b", indexes [0] and [-1], projections [*]
+// and *, flatten [], filters [?free > `0`], comparisons, && || ! and
+// pipes. Numeric strings are compared as numbers since Binance returns
+// most numbers as strings.
+
+// queryToken define a token of a query
+type queryToken struct {
+	kind  string // one of the punctuations, "ident", "number", "literal" or "eof"
+	value string
+	pos   int
+}
+
+// binding powers of tokens, projections stop at tokens binding below 10
+var queryBindingPowers = map[string]int{
+	"eof": 0, "ident": 0, "number": 0, "literal": 0, "]": 0, ")": 0,
+	"|": 1, "||": 2, "&&": 3, "!": 45,
+	"==": 5, "!=": 5, "<": 5, "<=": 5, ">": 5, ">=": 5,
+	"[]": 9, "*": 20, "[?": 21, ".": 40, "[": 55, "(": 60,
+}
+
+// lexQuery split a query into tokens
+func lexQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: "ident", value: string(runes[start:i]), pos: start})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: "number", value: string(runes[start:i]), pos: start})
+		case r == '"' || r == '\'' || r == '`':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, errors.Errorf("unterminated %c at %d", r, start)
+			}
+			i++
+			text := string(runes[start:i])
+			switch r {
+			case '"':
+				var name string
+				err := json.Unmarshal([]byte(text), &name)
+				if err != nil {
+					return nil, errors.Errorf("invalid quoted field at %d", start)
+				}
+				tokens = append(tokens, queryToken{kind: "ident", value: name, pos: start})
+			case '\'':
+				raw, _ := json.Marshal(strings.Replace(text[1:len(text)-1], "\\'", "'", -1))
+				tokens = append(tokens, queryToken{kind: "literal", value: string(raw), pos: start})
+			default:
+				tokens = append(tokens, queryToken{kind: "literal", value: text[1 : len(text)-1], pos: start})
+			}
+		default:
+			var op string
+			for _, p := range []string{"[?", "[]", "==", "!=", "<=", ">=", "&&", "||"} {
+				if strings.HasPrefix(string(runes[i:]), p) {
+					op = p
+					break
+				}
+			}
+			if op == "" && strings.ContainsRune(".[]*|!<>()@", r) {
+				op = string(r)
+			}
+			if op == "" {
+				return nil, errors.Errorf("unexpected %q at %d", r, i)
+			}
+			tokens = append(tokens, queryToken{kind: op, pos: i})
+			i += len([]rune(op))
+		}
+	}
+	return append(tokens, queryToken{kind: "eof", pos: len(runes)}), nil
+}
+
+// queryNode evaluate a parsed query on a value decoded by decodeOrdered
+type queryNode func(v interface{}) interface{}
+
+// queryParser is a Pratt parser of queries
+type queryParser struct {
+	tokens []queryToken
+	i      int
+}
+
+// parseQuery parse a query into a node
+func parseQuery(query string) (queryNode, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	p := &queryParser{tokens: tokens}
+	node, err := p.expression(0)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if p.peek().kind != "eof" {
+		return nil, p.unexpected()
+	}
+	return node, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.i]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.i]
+	if t.kind != "eof" {
+		p.i++
+	}
+	return t
+}
+
+func (p *queryParser) expect(kind string) error {
+	if p.peek().kind != kind {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+func (p *queryParser) unexpected() error {
+	return unexpectedToken(p.peek())
+}
+
+func unexpectedToken(t queryToken) error {
+	if t.kind == "eof" {
+		return errors.New("unexpected end of query")
+	}
+	return errors.Errorf("unexpected %s at %d", t.kind, t.pos)
+}
+
+func (p *queryParser) expression(bp int) (queryNode, error) {
+	left, err := p.nud(p.next())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for bp < queryBindingPowers[p.peek().kind] {
+		left, err = p.led(p.next(), left)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) nud(t queryToken) (queryNode, error) {
+	switch t.kind {
+	case "ident":
+		return queryField(t.value), nil
+	case "literal":
+		v, err := decodeOrdered([]byte(t.value))
+		if err != nil {
+			return nil, errors.Errorf("invalid literal at %d", t.pos)
+		}
+		return func(interface{}) interface{} { return v }, nil
+	case "@":
+		return queryCurrent, nil
+	case "*":
+		right, err := p.projectionRHS(queryBindingPowers["*"])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return queryValueProjection(queryCurrent, right), nil
+	case "[]":
+		right, err := p.projectionRHS(queryBindingPowers["[]"])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return queryProjection(queryFlatten(queryCurrent), right), nil
+	case "[":
+		return p.bracket(queryCurrent)
+	case "[?":
+		return p.filter(queryCurrent)
+	case "!":
+		expr, err := p.expression(queryBindingPowers["!"])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return func(v interface{}) interface{} { return !queryTruthy(expr(v)) }, nil
+	case "(":
+		expr, err := p.expression(0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return expr, errors.Trace(p.expect(")"))
+	}
+	return nil, unexpectedToken(t)
+}
+
+func (p *queryParser) led(t queryToken, left queryNode) (queryNode, error) {
+	switch t.kind {
+	case ".":
+		if p.peek().kind == "*" {
+			p.next()
+			right, err := p.projectionRHS(queryBindingPowers["*"])
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			return queryValueProjection(left, right), nil
+		}
+		right, err := p.dotRHS(queryBindingPowers["."])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return querySub(left, right), nil
+	case "[":
+		return p.bracket(left)
+	case "[?":
+		return p.filter(left)
+	case "[]":
+		right, err := p.projectionRHS(queryBindingPowers["[]"])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return queryProjection(queryFlatten(left), right), nil
+	case "|", "||", "&&", "==", "!=", "<", "<=", ">", ">=":
+		right, err := p.expression(queryBindingPowers[t.kind])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return queryBinary(t.kind, left, right), nil
+	}
+	return nil, unexpectedToken(t)
+}
+
+// bracket parse an index [0] or a projection [*] after left
+func (p *queryParser) bracket(left queryNode) (queryNode, error) {
+	t := p.next()
+	switch t.kind {
+	case "number":
+		index, err := strconv.Atoi(t.value)
+		if err != nil {
+			return nil, errors.Errorf("invalid index at %d", t.pos)
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return querySub(left, queryIndex(index)), nil
+	case "*":
+		if err := p.expect("]"); err != nil {
+			return nil, errors.Trace(err)
+		}
+		right, err := p.projectionRHS(queryBindingPowers["*"])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return queryProjection(left, right), nil
+	}
+	return nil, unexpectedToken(t)
+}
+
+// filter parse a filter projection [?cond] after left
+func (p *queryParser) filter(left queryNode) (queryNode, error) {
+	cond, err := p.expression(0)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	right, err := p.projectionRHS(queryBindingPowers["[?"])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return func(v interface{}) interface{} {
+		list, ok := left(v).([]interface{})
+		if !ok {
+			return nil
+		}
+		res := []interface{}{}
+		for _, item := range list {
+			if queryTruthy(cond(item)) {
+				if r := right(item); r != nil {
+					res = append(res, r)
+				}
+			}
+		}
+		return res
+	}, nil
+}
+
+// projectionRHS parse the expression applied to each item of a projection
+func (p *queryParser) projectionRHS(bp int) (queryNode, error) {
+	switch t := p.peek(); {
+	case queryBindingPowers[t.kind] < 10:
+		return queryCurrent, nil
+	case t.kind == "[" || t.kind == "[?" || t.kind == "[]":
+		return p.expression(bp)
+	case t.kind == ".":
+		p.next()
+		return p.dotRHS(bp)
+	}
+	return nil, p.unexpected()
+}
+
+// dotRHS parse the expression after a dot
+func (p *queryParser) dotRHS(bp int) (queryNode, error) {
+	switch p.peek().kind {
+	case "ident":
+		return p.expression(bp)
+	case "[", "[?", "[]":
+		return p.expression(bp)
+	}
+	return nil, p.unexpected()
+}
+
+func queryCurrent(v interface{}) interface{} {
+	return v
+}
+
+func queryField(name string) queryNode {
+	return func(v interface{}) interface{} {
+		if obj, ok := v.(*orderedObject); ok {
+			return obj.values[name]
+		}
+		return nil
+	}
+}
+
+func queryIndex(index int) queryNode {
+	return func(v interface{}) interface{} {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		i := index
+		if i < 0 {
+			i += len(list)
+		}
+		if i < 0 || i >= len(list) {
+			return nil
+		}
+		return list[i]
+	}
+}
+
+func querySub(left, right queryNode) queryNode {
+	return func(v interface{}) interface{} {
+		l := left(v)
+		if l == nil {
+			return nil
+		}
+		return right(l)
+	}
+}
+
+// queryProjection apply right to each item of the list of left, null
+// results are dropped
+func queryProjection(left, right queryNode) queryNode {
+	return func(v interface{}) interface{} {
+		list, ok := left(v).([]interface{})
+		if !ok {
+			return nil
+		}
+		res := []interface{}{}
+		for _, item := range list {
+			if r := right(item); r != nil {
+				res = append(res, r)
+			}
+		}
+		return res
+	}
+}
+
+// queryValueProjection apply right to each value of the object of left
+func queryValueProjection(left, right queryNode) queryNode {
+	return func(v interface{}) interface{} {
+		obj, ok := left(v).(*orderedObject)
+		if !ok {
+			return nil
+		}
+		values := make([]interface{}, len(obj.keys))
+		for i, key := range obj.keys {
+			values[i] = obj.values[key]
+		}
+		return queryProjection(queryCurrent, right)(values)
+	}
+}
+
+// queryFlatten merge lists in the list of left into it
+func queryFlatten(left queryNode) queryNode {
+	return func(v interface{}) interface{} {
+		list, ok := left(v).([]interface{})
+		if !ok {
+			return nil
+		}
+		res := []interface{}{}
+		for _, item := range list {
+			if sub, ok := item.([]interface{}); ok {
+				res = append(res, sub...)
+			} else {
+				res = append(res, item)
+			}
+		}
+		return res
+	}
+}
+
+// queryTruthy return false for null, false, empty strings, lists and objects
+func queryTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case *orderedObject:
+		return len(v.keys) > 0
+	}
+	return true
+}
+
+// queryNumber return v as a number, numeric strings included
+func queryNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// queryEqual compare values, numbers and numeric strings by value
+func queryEqual(a, b interface{}) bool {
+	x, ok1 := queryNumber(a)
+	y, ok2 := queryNumber(b)
+	if ok1 && ok2 {
+		return x == y
+	}
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return bytes.Equal(da, db)
+}
+
+func queryBinary(op string, left, right queryNode) queryNode {
+	return func(v interface{}) interface{} {
+		l := left(v)
+		switch op {
+		case "|":
+			return right(l)
+		case "||":
+			if queryTruthy(l) {
+				return l
+			}
+			return right(v)
+		case "&&":
+			if !queryTruthy(l) {
+				return l
+			}
+			return right(v)
+		}
+		r := right(v)
+		switch op {
+		case "==":
+			return queryEqual(l, r)
+		case "!=":
+			return !queryEqual(l, r)
+		}
+		x, ok1 := queryNumber(l)
+		y, ok2 := queryNumber(r)
+		if !ok1 || !ok2 {
+			return nil
+		}
+		switch op {
+		case "<":
+			return x < y
+		case "<=":
+			return x <= y
+		case ">":
+			return x > y
+		}
+		return x >= y
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+const redacted = "***"
+
+// relativeKeys are kept by redaction as they do not reveal holdings,
+// keys containing any of them are matched case-insensitively
+var relativeKeys = []string{"price", "percent", "rate", "return", "bps", "time", "rank", "interval", "period"}
+
+// secretKeys are always redacted, matched as suffixes. Ids are redacted
+// too, matched as the last word of keys like id, order_id or orderId.
+var secretKeys = []string{"address", "tag", "txid", "listenkey", "memo"}
+
+// redactor replace account names by aliases and mask absolute figures and ids
+type redactor struct {
+	aliases map[string]string
+	// names are account names, longest first
+	names []string
+}
+
+func newRedactor() *redactor {
+	var names []string
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	aliases := make(map[string]string)
+	for i, name := range names {
+		aliases[name] = fmt.Sprintf("account-%d", i+1)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	return &redactor{aliases: aliases, names: names}
+}
+
+// isWordByte return true for bytes of account names and words around them
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// aliasNames replace account names appearing as whole words in s, like in
+// error messages, by their aliases
+func (r *redactor) aliasNames(s string) string {
+	for _, name := range r.names {
+		if name == "" || !strings.Contains(s, name) {
+			continue
+		}
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			j := strings.Index(s[i:], name)
+			if j < 0 {
+				b.WriteString(s[i:])
+				break
+			}
+			j += i
+			end := j + len(name)
+			if (j > 0 && isWordByte(s[j-1])) || (end < len(s) && isWordByte(s[end])) {
+				b.WriteString(s[i : j+1])
+				i = j + 1
+				continue
+			}
+			b.WriteString(s[i:j])
+			b.WriteString(r.aliases[name])
+			i = end
+		}
+		s = b.String()
+	}
+	return s
+}
+
+// redact return a copy of v safe for sharing
+func redact(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var res interface{}
+	err = decoder.Decode(&res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newRedactor().value("", res), nil
+}
+
+func isRelativeKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range relativeKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords split a key into lower case words by underscores and camel
+// case: orderId, order_id and orderID are order and id
+func keyWords(key string) []string {
+	var words []string
+	var word []rune
+	prevLower := false
+	for _, r := range key {
+		upper := r >= 'A' && r <= 'Z'
+		if r == '_' || (upper && prevLower) {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+			}
+			word = nil
+		}
+		if r != '_' {
+			word = append(word, r)
+		}
+		prevLower = !upper && r != '_'
+	}
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+	return words
+}
+
+func isSecretKey(key string) bool {
+	words := keyWords(key)
+	if len(words) > 0 && (words[len(words)-1] == "id" || words[len(words)-1] == "ids") {
+		return true
+	}
+	key = strings.Join(words, "")
+	for _, k := range secretKeys {
+		if strings.HasSuffix(key, k) || strings.HasSuffix(key, k+"s") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) value(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{})
+		for k, item := range v {
+			if alias, ok := r.aliases[k]; ok {
+				res[alias] = r.value(key, item)
+				continue
+			}
+			res[k] = r.value(k, item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = r.value(key, item)
+		}
+		return res
+	case json.Number:
+		if isSecretKey(key) || !isRelativeKey(key) {
+			return redacted
+		}
+		return v
+	case string:
+		if alias, ok := r.aliases[v]; ok {
+			return alias
+		}
+		if isSecretKey(key) {
+			return redacted
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil && !isRelativeKey(key) {
+			return redacted
+		}
+		return r.aliasNames(v)
+	}
+	return v
+}
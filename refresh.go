@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ANSI escape codes of the refresh screen
+const (
+	clearScreen   = "\033[H\033[2J"
+	highlightLine = "\033[7m"
+	resetStyle    = "\033[0m"
+)
+
+// refreshScreen redraw printed results in place, lines changed since the
+// last draw are highlighted
+type refreshScreen struct {
+	title    string
+	interval time.Duration
+	terminal bool
+	last     []string
+}
+
+// refresh is the screen printed results are drawn on in watch mode
+var refresh *refreshScreen
+
+// isTerminal return true if stdout is a terminal
+func isTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// changedLines return indexes of lines not in the longest common
+// subsequence of prev and lines
+func changedLines(prev, lines []string) map[int]bool {
+	// lcs[i][j] is the length of the LCS of prev[i:] and lines[j:]
+	lcs := make([][]int, len(prev)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(lines)+1)
+	}
+	for i := len(prev) - 1; i >= 0; i-- {
+		for j := len(lines) - 1; j >= 0; j-- {
+			if prev[i] == lines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	changed := make(map[int]bool)
+	i, j := 0, 0
+	for j < len(lines) {
+		switch {
+		case i < len(prev) && prev[i] == lines[j]:
+			i++
+			j++
+		case i < len(prev) && lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			changed[j] = true
+			j++
+		}
+	}
+	return changed
+}
+
+// Draw clear the screen and draw output with changed lines highlighted,
+// output is printed as is if stdout is not a terminal
+func (s *refreshScreen) Draw(output string) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if !s.terminal {
+		fmt.Println(output)
+		s.last = lines
+		return
+	}
+	var changed map[int]bool
+	if s.last != nil {
+		changed = changedLines(s.last, lines)
+	}
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	fmt.Fprintf(&b, "Every %s: %s    %s\n\n", s.interval, s.title, time.Now().Format("2006-01-02 15:04:05"))
+	for i, line := range lines {
+		if changed[i] {
+			b.WriteString(highlightLine + line + resetStyle + "\n")
+		} else {
+			b.WriteString(line + "\n")
+		}
+	}
+	fmt.Print(b.String())
+	s.last = lines
+}
+
+// watchRefresh run action every interval and redraw its printed result in
+// place, action is run once if interval is 0. Errors of action are drawn
+// instead of stopping the refresh.
+func watchRefresh(title string, interval time.Duration, action func() error) error {
+	if interval <= 0 {
+		return action()
+	}
+	// prices cached longer than interval would be shown again as latest
+	if priceCacheTTL > interval {
+		priceCacheTTL = interval
+	}
+	refresh = &refreshScreen{title: title, interval: interval, terminal: isTerminal()}
+	for {
+		err := action()
+		if err != nil {
+			refresh.Draw(fmt.Sprintf("%s: %s", T("error"), err))
+		}
+		time.Sleep(interval)
+	}
+}
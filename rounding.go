@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// rounding modes of totals
+const (
+	roundHalfUp   = "half-up"
+	roundHalfEven = "half-even"
+)
+
+var (
+	roundingMode string
+	// decimalsSpec is decimal places of totals by asset: USDT=2,BTC=8,*=8
+	decimalsSpec string
+	// decimalPlaces is parsed decimalsSpec, "*" for other assets
+	decimalPlaces map[string]int
+)
+
+// initRounding validate rounding mode and parse decimal places of totals
+func initRounding() error {
+	switch roundingMode {
+	case roundHalfUp, roundHalfEven:
+	default:
+		return errors.Errorf("invalid rounding mode: %s", roundingMode)
+	}
+	decimalPlaces = make(map[string]int)
+	for _, item := range SplitItems([]string{decimalsSpec}) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("invalid decimals: %s", item)
+		}
+		places, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || places < 0 {
+			return errors.Errorf("invalid decimals: %s", item)
+		}
+		decimalPlaces[strings.ToUpper(strings.TrimSpace(parts[0]))] = places
+	}
+	return nil
+}
+
+// roundTotal round total v of asset to its configured decimal places,
+// v is returned as is if no decimal places are configured for asset
+func roundTotal(v float64, asset string) float64 {
+	places, ok := decimalPlaces[strings.ToUpper(asset)]
+	if !ok {
+		places, ok = decimalPlaces["*"]
+	}
+	if !ok {
+		return v
+	}
+	return roundDecimal(v, places, roundingMode)
+}
+
+// roundDecimal round v to places on its shortest decimal representation,
+// so that e.g. 1.005 is rounded half-up to 1.01 rather than 1.00
+func roundDecimal(v float64, places int, mode string) float64 {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	if !ok {
+		return v
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+	neg := r.Sign() < 0
+	r.Abs(r)
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	switch new(big.Int).Mul(m, big.NewInt(2)).Cmp(r.Denom()) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if mode != roundHalfEven || q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if neg {
+		q.Neg(q)
+	}
+	f, _ := new(big.Rat).SetFrac(q, scale).Float64()
+	return f
+}
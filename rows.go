@@ -0,0 +1,230 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// rowSort define a sort of lists of objects by a field
+type rowSort struct {
+	field string
+	desc  bool
+}
+
+// parseSortBy parse field[:asc|desc]
+func parseSortBy(spec string) (*rowSort, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	s := &rowSort{field: strings.TrimSpace(parts[0])}
+	if s.field == "" {
+		return nil, errors.Errorf("invalid sort-by: %s", spec)
+	}
+	if len(parts) == 2 {
+		switch strings.ToLower(parts[1]) {
+		case "asc":
+		case "desc":
+			s.desc = true
+		default:
+			return nil, errors.Errorf("invalid sort order: %s", parts[1])
+		}
+	}
+	return s, nil
+}
+
+// lookupField return the value of a dotted field of obj, keys are matched
+// case-insensitively
+func lookupField(obj *orderedObject, field string) (interface{}, bool) {
+	var v interface{} = obj
+	for _, key := range strings.Split(field, ".") {
+		o, ok := v.(*orderedObject)
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for _, k := range o.keys {
+			if strings.EqualFold(k, key) {
+				v, found = o.values[k], true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// compareValues compare numbers and numeric strings by value and other
+// values by text, nil is less than other values
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	x, ok1 := queryNumber(a)
+	y, ok2 := queryNumber(b)
+	if ok1 && ok2 {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(cellText(a), cellText(b))
+}
+
+// Sort sort lists of objects having the field in v, nested lists included.
+// Items without the field are kept last.
+func (s *rowSort) Sort(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		sortable := false
+		for _, item := range v {
+			if obj, ok := item.(*orderedObject); ok {
+				if _, ok := lookupField(obj, s.field); ok {
+					sortable = true
+				}
+			}
+			s.Sort(item)
+		}
+		if !sortable {
+			return v
+		}
+		sort.SliceStable(v, func(i, j int) bool {
+			a, okA := s.value(v[i])
+			b, okB := s.value(v[j])
+			if !okA || !okB {
+				return okA && !okB
+			}
+			if s.desc {
+				return compareValues(a, b) > 0
+			}
+			return compareValues(a, b) < 0
+		})
+	case *orderedObject:
+		for _, key := range v.keys {
+			s.Sort(v.values[key])
+		}
+	}
+	return v
+}
+
+// value return the sort field of item
+func (s *rowSort) value(item interface{}) (interface{}, bool) {
+	obj, ok := item.(*orderedObject)
+	if !ok {
+		return nil, false
+	}
+	return lookupField(obj, s.field)
+}
+
+// filterOps are operators of row filters, longer ones first
+var filterOps = []string{">=", "<=", "!=", "==", "=", ">", "<", "~"}
+
+// rowFilter define a filter of objects by comparing a field with a value
+type rowFilter struct {
+	field string
+	op    string
+	value string
+}
+
+// parseFilter parse field op value: free>0.01, side=BUY
+func parseFilter(spec string) (*rowFilter, error) {
+	for i := range spec {
+		for _, op := range filterOps {
+			if strings.HasPrefix(spec[i:], op) {
+				f := &rowFilter{
+					field: strings.TrimSpace(spec[:i]),
+					op:    op,
+					value: strings.TrimSpace(spec[i+len(op):]),
+				}
+				if f.field == "" {
+					return nil, errors.Errorf("invalid filter: %s", spec)
+				}
+				return f, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("invalid filter, operator required: %s", spec)
+}
+
+// Match return true if the field of obj matches, strings are compared
+// case-insensitively
+func (f *rowFilter) Match(obj *orderedObject) bool {
+	v, ok := lookupField(obj, f.field)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "~":
+		return strings.Contains(strings.ToLower(cellText(v)), strings.ToLower(f.value))
+	case "=", "==":
+		return compareValues(v, f.value) == 0 || strings.EqualFold(cellText(v), f.value)
+	case "!=":
+		return compareValues(v, f.value) != 0 && !strings.EqualFold(cellText(v), f.value)
+	}
+	_, ok1 := queryNumber(v)
+	_, ok2 := queryNumber(f.value)
+	if !ok1 || !ok2 {
+		return false
+	}
+	c := compareValues(v, f.value)
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// filterRows drop items of lists of objects in v not matching all filters,
+// nested lists included. Lists without any filtered field are kept as is.
+func filterRows(v interface{}, filters []*rowFilter) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		filtered := false
+		for i, item := range v {
+			v[i] = filterRows(item, filters)
+			if obj, ok := item.(*orderedObject); ok {
+				for _, f := range filters {
+					if _, ok := lookupField(obj, f.field); ok {
+						filtered = true
+					}
+				}
+			}
+		}
+		if !filtered {
+			return v
+		}
+		res := []interface{}{}
+	items:
+		for _, item := range v {
+			obj, ok := item.(*orderedObject)
+			if !ok {
+				continue
+			}
+			for _, f := range filters {
+				if !f.Match(obj) {
+					continue items
+				}
+			}
+			res = append(res, item)
+		}
+		return res
+	case *orderedObject:
+		for _, key := range v.keys {
+			v.values[key] = filterRows(v.values[key], filters)
+		}
+	}
+	return v
+}
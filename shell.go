@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// shellHistoryFile is the file in data dir of lines entered in the shell
+const shellHistoryFile = "shell_history.jsonl"
+
+// shellBuiltins are commands handled by the shell itself
+var shellBuiltins = []string{"use", "exit", "quit"}
+
+// shell run commands of app entered line by line, global flags the shell
+// was started with and the account selected by use apply to every command
+type shell struct {
+	app      *cli.App
+	globals  []string
+	account  string
+	history  []string
+	commands map[string]*completionCommand
+	terminal bool
+	reader   *bufio.Reader
+}
+
+// newShell create a shell of app started with global flags
+func newShell(app *cli.App, globals []string) *shell {
+	s := &shell{
+		app:      app,
+		globals:  globals,
+		commands: make(map[string]*completionCommand),
+		terminal: isTerminal() && stdinTerminal(),
+		reader:   bufio.NewReader(os.Stdin),
+	}
+	root := &completionCommand{
+		Commands: newCompletionCommands(app.Commands, ""),
+		Flags:    newCompletionFlags(app.VisibleFlags()),
+	}
+	root.walk(func(c *completionCommand) {
+		s.commands[c.Path] = c
+	})
+	readRecords(shellHistoryFile, func(data []byte) error {
+		var line string
+		if json.Unmarshal(data, &line) == nil {
+			s.history = append(s.history, line)
+		}
+		return nil
+	})
+	return s
+}
+
+// stdinTerminal return true if stdin is a terminal
+func stdinTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stty run stty on the terminal of stdin with args
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), errors.Trace(err)
+}
+
+// prompt return the prompt showing the selected account
+func (s *shell) prompt() string {
+	if s.account != "" {
+		return fmt.Sprintf("%s[%s]> ", s.app.Name, s.account)
+	}
+	return s.app.Name + "> "
+}
+
+// readLine read a line with history and tab completion if stdin is a
+// terminal, io.EOF is returned on ctrl-d of an empty line
+func (s *shell) readLine() (string, error) {
+	prompt := s.prompt()
+	if !s.terminal {
+		line, err := s.reader.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimSpace(line), err
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	_, err = stty("raw", "-echo")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer stty(state)
+
+	var line []rune
+	index := len(s.history)
+	redraw := func() {
+		fmt.Print("\r\033[K" + prompt + string(line))
+	}
+	redraw()
+	for {
+		r, _, err := s.reader.ReadRune()
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Print("\r\n")
+			return strings.TrimSpace(string(line)), nil
+		case 3: // ctrl-c
+			fmt.Print("^C\r\n")
+			line, index = nil, len(s.history)
+		case 4: // ctrl-d
+			if len(line) == 0 {
+				fmt.Print("\r\n")
+				return "", io.EOF
+			}
+		case 21: // ctrl-u
+			line = nil
+		case 8, 127:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case '\t':
+			completed, candidates := s.complete(string(line))
+			line = []rune(completed)
+			if len(candidates) > 1 {
+				fmt.Print("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+			}
+		case 27: // arrow keys: ESC [ A
+			if b, _ := s.reader.ReadByte(); b != '[' {
+				continue
+			}
+			switch b, _ := s.reader.ReadByte(); b {
+			case 'A':
+				if index > 0 {
+					index--
+					line = []rune(s.history[index])
+				}
+			case 'B':
+				if index < len(s.history) {
+					index++
+					line = nil
+					if index < len(s.history) {
+						line = []rune(s.history[index])
+					}
+				}
+			}
+		default:
+			if r >= ' ' {
+				line = append(line, r)
+			}
+		}
+		redraw()
+	}
+}
+
+// complete complete the last word of line, candidates are returned if
+// the word can not be completed uniquely
+func (s *shell) complete(line string) (string, []string) {
+	words := strings.Fields(line)
+	current := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		current, words = words[len(words)-1], words[:len(words)-1]
+	}
+	var candidates []string
+	prev := ""
+	if len(words) > 0 {
+		prev = words[len(words)-1]
+	}
+	kind := ""
+	if strings.HasPrefix(prev, "--") {
+		kind = completedNames(prev[2:])
+	}
+	switch {
+	case len(words) == 1 && prev == "use":
+		candidates, _ = completionNames("accounts")
+	case kind != "":
+		candidates, _ = completionNames(kind)
+	default:
+		path := ""
+		for _, word := range words {
+			next := strings.TrimSpace(path + " " + word)
+			if _, ok := s.commands[next]; ok {
+				path = next
+			}
+		}
+		candidates = s.commands[path].words()
+		if len(words) == 0 {
+			candidates = append(candidates, shellBuiltins...)
+		}
+	}
+	var matched []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) {
+			matched = append(matched, c)
+		}
+	}
+	sort.Strings(matched)
+	if len(matched) == 0 {
+		return line, nil
+	}
+	prefix := matched[0]
+	for _, m := range matched[1:] {
+		for !strings.HasPrefix(m, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	completed := line[:len(line)-len(current)] + prefix
+	if len(matched) == 1 {
+		return completed + " ", nil
+	}
+	return completed, matched
+}
+
+// splitArgs split line into args like a shell, with single and double
+// quotes and backslash escapes
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// use select the account of following commands, all accounts if empty
+func (s *shell) use(account string) error {
+	if account != "" {
+		names, _ := completionNames("accounts")
+		found := false
+		for _, name := range names {
+			found = found || name == account
+		}
+		if !found {
+			return errors.NotFoundf("account %s", account)
+		}
+	}
+	s.account = account
+	return nil
+}
+
+// run run a command of app with the global flags and selected account
+func (s *shell) run(args []string) error {
+	if args[0] == "shell" {
+		return errors.New("already in the shell")
+	}
+	full := append([]string{s.app.Name}, s.globals...)
+	if s.account != "" {
+		full = append(full, "--name", s.account)
+	}
+	full = append(full, args...)
+	// reset state left by the previous command
+	outputFilters = nil
+	skipHistory = false
+	resultSummary = ""
+	fanOutStagger, fanOutJitter, fanOutShuffle = 0, 0, false
+	err := s.app.Run(full)
+	recordHistory(full[1:], err)
+	return errors.Trace(err)
+}
+
+// Run read and run commands until exit or EOF
+func (s *shell) Run() error {
+	for {
+		line, err := s.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if line == "" {
+			continue
+		}
+		if len(s.history) == 0 || s.history[len(s.history)-1] != line {
+			s.history = append(s.history, line)
+			appendRecord(shellHistoryFile, line)
+		}
+		args, err := splitArgs(line)
+		if err != nil {
+			logError(err)
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "use":
+			err = s.use(strings.Join(args[1:], " "))
+		default:
+			err = s.run(args)
+		}
+		if err != nil {
+			logError(err)
+		}
+	}
+}
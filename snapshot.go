@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const snapshotsFile = "snapshots.jsonl"
+
+// BalanceSnapshot define a portfolio of accounts recorded at a time
+type BalanceSnapshot struct {
+	Time int64 `json:"time"`
+	*Portfolio
+}
+
+// recordSnapshot value balances of wallets of accounts in quote asset and
+// append them to the snapshot store
+func recordSnapshot(wallets []string, quote string) error {
+	return valuePortfolio(wallets, quote, func(portfolio *Portfolio) error {
+		snapshot := &BalanceSnapshot{
+			Time:      MilliTime(time.Now()),
+			Portfolio: portfolio,
+		}
+		return errors.Trace(appendRecord(snapshotsFile, snapshot))
+	})
+}
+
+// loadSnapshots call fn with each recorded snapshot in order of time
+func loadSnapshots(fn func(*BalanceSnapshot) error) error {
+	return readRecords(snapshotsFile, func(data []byte) error {
+		snapshot := new(BalanceSnapshot)
+		err := json.Unmarshal(data, snapshot)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return fn(snapshot)
+	})
+}
+
+// showSnapshot show the latest snapshot taken at or before at, a date
+// without time means the end of that day
+func showSnapshot(at string) error {
+	atTime := MilliTime(time.Now())
+	if at != "" {
+		var err error
+		atTime, err = ParseTime(at)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := time.Parse("2006-01-02", at); err == nil {
+			atTime += int64(24*time.Hour/time.Millisecond) - 1
+		}
+	}
+	var res *BalanceSnapshot
+	err := loadSnapshots(func(snapshot *BalanceSnapshot) error {
+		if snapshot.Time <= atTime && (res == nil || snapshot.Time >= res.Time) {
+			res = snapshot
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if res == nil {
+		return errors.NotFoundf("snapshot at %s", at)
+	}
+	return print(res)
+}
+
+// SnapshotPoint define totals of a snapshot, with amount and value of an
+// asset if set
+type SnapshotPoint struct {
+	Time      int64              `json:"time"`
+	Quote     string             `json:"quote"`
+	Total     float64            `json:"total"`
+	Subtotals map[string]float64 `json:"subtotals"`
+	Amount    *float64           `json:"amount,omitempty"`
+	Value     *float64           `json:"value,omitempty"`
+}
+
+// listSnapshots list totals of snapshots between startTime and endTime for
+// charting, with amount and value of asset held if set
+func listSnapshots(asset string, startTime, endTime int64) error {
+	asset = strings.ToUpper(asset)
+	var points []*SnapshotPoint
+	err := loadSnapshots(func(snapshot *BalanceSnapshot) error {
+		if snapshot.Time < startTime || (endTime > 0 && snapshot.Time > endTime) || snapshot.Portfolio == nil {
+			return nil
+		}
+		point := &SnapshotPoint{
+			Time:      snapshot.Time,
+			Quote:     snapshot.Quote,
+			Total:     snapshot.Total,
+			Subtotals: snapshot.Subtotals,
+		}
+		if asset != "" {
+			amount := 0.0
+			point.Amount = &amount
+			for _, item := range snapshot.Assets {
+				if item.Asset == asset {
+					point.Amount = &item.Amount
+					point.Value = item.Value
+				}
+			}
+		}
+		points = append(points, point)
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return print(points)
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// liquidStakingEndpoints define endpoints of liquid staking products by staked asset
+var liquidStakingEndpoints = map[string]struct {
+	token          string
+	account        string
+	rateHistory    string
+	rewardsHistory string
+}{
+	"ETH": {
+		token:          "WBETH",
+		account:        "/sapi/v2/eth-staking/account",
+		rateHistory:    "/sapi/v1/eth-staking/eth/history/rateHistory",
+		rewardsHistory: "/sapi/v1/eth-staking/eth/history/wbethRewardsHistory",
+	},
+	"SOL": {
+		token:          "BNSOL",
+		account:        "/sapi/v1/sol-staking/account",
+		rateHistory:    "/sapi/v1/sol-staking/sol/history/rateHistory",
+		rewardsHistory: "/sapi/v1/sol-staking/sol/history/bnsolRewardsHistory",
+	},
+}
+
+// StakingReward define a daily reward of liquid staking
+type StakingReward struct {
+	Time      int64  `json:"time"`
+	Amount    string `json:"amount"`
+	Holding   string `json:"holding"`
+	HoldingIn string `json:"holdingIn"`
+	APR       string `json:"annualPercentageRate"`
+}
+
+// LiquidStaking define liquid staking position of ETH (WBETH) or SOL (BNSOL)
+type LiquidStaking struct {
+	Asset            string           `json:"asset"`
+	Token            string           `json:"token"`
+	Holding          string           `json:"holding"`
+	ThirtyDaysProfit string           `json:"thirtyDaysProfit"`
+	ExchangeRate     string           `json:"exchangeRate"`
+	APR              string           `json:"annualPercentageRate"`
+	EstRewards       string           `json:"estRewards"`
+	Rewards          []*StakingReward `json:"rewards"`
+	TotalRewards     float64          `json:"totalRewards"`
+}
+
+func strValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// GetLiquidStaking get liquid staking position of asset ETH or SOL with
+// latest conversion rate and rewards between startTime and endTime
+func (account *Account) GetLiquidStaking(asset string, startTime, endTime int64) (*LiquidStaking, error) {
+	asset = strings.ToUpper(asset)
+	endpoints, ok := liquidStakingEndpoints[asset]
+	if !ok {
+		return nil, errors.NotSupportedf("liquid staking of %s", asset)
+	}
+	ctx, cancel := newContext()
+	defer cancel()
+	staking := &LiquidStaking{Asset: asset, Token: endpoints.token}
+
+	var accountRes map[string]interface{}
+	err := account.callAPI(ctx, http.MethodGet, apiURL, endpoints.account, nil, true, &accountRes)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	suffix := "In" + asset
+	staking.Holding = strValue(accountRes["holding"+suffix])
+	staking.ThirtyDaysProfit = strValue(accountRes["thirtyDaysProfit"+suffix])
+
+	rates := new(struct {
+		Rows []struct {
+			APR          string `json:"annualPercentageRate"`
+			ExchangeRate string `json:"exchangeRate"`
+			Time         int64  `json:"time"`
+		} `json:"rows"`
+	})
+	err = account.callAPI(ctx, http.MethodGet, apiURL, endpoints.rateHistory,
+		url.Values{"size": {"1"}}, true, rates)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(rates.Rows) > 0 {
+		staking.ExchangeRate = rates.Rows[0].ExchangeRate
+		staking.APR = rates.Rows[0].APR
+	}
+
+	params := url.Values{"size": {"100"}}
+	setTimeRange(params, 0, startTime, endTime)
+	// reward fields are suffixed with the staked asset, e.g. amountInETH
+	var rawRewards map[string]interface{}
+	err = account.callAPI(ctx, http.MethodGet, apiURL, endpoints.rewardsHistory, params, true, &rawRewards)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	staking.EstRewards = strValue(rawRewards["estRewards"+suffix])
+	rows, _ := rawRewards["rows"].([]interface{})
+	for _, r := range rows {
+		row, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		reward := &StakingReward{
+			Amount:    strValue(row["amount"+suffix]),
+			Holding:   strValue(row["holding"]),
+			HoldingIn: strValue(row["holding"+suffix]),
+			APR:       strValue(row["annualPercentageRate"]),
+		}
+		if t, ok := row["time"].(float64); ok {
+			reward.Time = int64(t)
+		}
+		staking.Rewards = append(staking.Rewards, reward)
+		staking.TotalRewards += StrToFloat(reward.Amount)
+	}
+	staking.TotalRewards = roundTotal(staking.TotalRewards, asset)
+	return staking, nil
+}
+
+// liquidStakingSpan is the max time range of a request of liquid staking rewards
+const liquidStakingSpan = 90 * 24 * time.Hour
+
+// showLiquidStaking show liquid staking position of asset with rewards of
+// the page, total rewards are of the rewards shown
+func showLiquidStaking(asset string, page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var staking *LiquidStaking
+		err := page.Fetch(liquidStakingSpan, func(startTime, endTime int64) error {
+			res, err := account.GetLiquidStaking(asset, startTime, endTime)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if staking == nil {
+				staking = res
+			} else {
+				staking.Rewards = append(staking.Rewards, res.Rewards...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		staking.Rewards = page.Apply(staking.Rewards).([]*StakingReward)
+		staking.TotalRewards = 0
+		for _, reward := range staking.Rewards {
+			staking.TotalRewards += StrToFloat(reward.Amount)
+		}
+		staking.TotalRewards = roundTotal(staking.TotalRewards, strings.ToUpper(asset))
+		return staking, nil
+	})
+}
+
+// stakingProducts are staking product types: locked staking, flexible and locked DeFi staking
+var stakingProducts = []string{"STAKING", "F_DEFI", "L_DEFI"}
+
+// StakingPosition define a position of a staking product
+type StakingPosition struct {
+	PositionID          json.Number `json:"positionId"`
+	ProductID           string      `json:"productId"`
+	Product             string      `json:"product"`
+	Asset               string      `json:"asset"`
+	Amount              string      `json:"amount"`
+	PurchaseTime        int64       `json:"purchaseTime"`
+	Duration            json.Number `json:"duration"`
+	AccrualDays         json.Number `json:"accrualDays"`
+	RewardAsset         string      `json:"rewardAsset"`
+	APY                 string      `json:"APY"`
+	RewardAmt           string      `json:"rewardAmt"`
+	NextInterestPayDate int64       `json:"nextInterestPayDate"`
+	InterestEndDate     int64       `json:"interestEndDate"`
+	DeliverDate         int64       `json:"deliverDate"`
+	RedeemPeriod        json.Number `json:"redeemPeriod"`
+	RedeemingAmt        string      `json:"redeemingAmt"`
+	CanRedeemEarly      bool        `json:"canRedeemEarly"`
+	Renewable           bool        `json:"renewable"`
+	Type                string      `json:"type"`
+	Status              string      `json:"status"`
+}
+
+// ListStakingPositions list positions of staking product: STAKING, F_DEFI or L_DEFI
+func (account *Account) ListStakingPositions(product string) ([]*StakingPosition, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var positions []*StakingPosition
+	for current := 1; ; current++ {
+		params := url.Values{
+			"product": {product},
+			"current": {strconv.Itoa(current)},
+			"size":    {"100"},
+		}
+		var res []*StakingPosition
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/staking/position", params, true, &res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, p := range res {
+			p.Product = product
+		}
+		positions = append(positions, res...)
+		if len(res) < 100 {
+			return positions, nil
+		}
+	}
+}
+
+// StakingPositions define staking positions of an account, liquid staking
+// of ETH and SOL is included if held
+type StakingPositions struct {
+	Positions []*StakingPosition `json:"positions"`
+	Liquid    []*LiquidStaking   `json:"liquid,omitempty"`
+}
+
+// listStakingPositions list staking positions of products, all products if
+// empty, with liquid ETH and SOL staking
+func listStakingPositions(products []string) error {
+	if len(products) == 0 {
+		products = stakingProducts
+	}
+	for i, product := range products {
+		var err error
+		products[i], err = checkStakingProduct(product)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		res := new(StakingPositions)
+		for _, product := range products {
+			positions, err := account.ListStakingPositions(product)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			res.Positions = append(res.Positions, positions...)
+		}
+		for _, asset := range []string{"ETH", "SOL"} {
+			staking, err := account.GetLiquidStaking(asset, 0, 0)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if StrToFloat(staking.Holding) > 0 {
+				// rewards are shown by eth-staking and sol-staking
+				staking.Rewards = nil
+				res.Liquid = append(res.Liquid, staking)
+			}
+		}
+		return res, nil
+	})
+}
+
+// StakingProduct define a staking product available for subscription
+type StakingProduct struct {
+	ProjectID string `json:"projectId"`
+	Detail    struct {
+		Asset       string      `json:"asset"`
+		RewardAsset string      `json:"rewardAsset"`
+		Duration    json.Number `json:"duration"`
+		Renewable   bool        `json:"renewable"`
+		APY         string      `json:"apy"`
+	} `json:"detail"`
+	Quota struct {
+		TotalPersonalQuota string `json:"totalPersonalQuota"`
+		Minimum            string `json:"minimum"`
+	} `json:"quota"`
+}
+
+// ListStakingProducts list staking products of product type, filtered by asset if set
+func (account *Account) ListStakingProducts(product, asset string) ([]*StakingProduct, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var products []*StakingProduct
+	for current := 1; ; current++ {
+		params := url.Values{
+			"product": {product},
+			"current": {strconv.Itoa(current)},
+			"size":    {"100"},
+		}
+		if asset != "" {
+			params.Set("asset", strings.ToUpper(asset))
+		}
+		var res []*StakingProduct
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/staking/productList", params, true, &res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		products = append(products, res...)
+		if len(res) < 100 {
+			return products, nil
+		}
+	}
+}
+
+// Stake subscribe amount to staking product, return id of the position
+func (account *Account) Stake(product, productID, amount string, renewable bool) (string, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"product":   {product},
+		"productId": {productID},
+		"amount":    {amount},
+		"renewable": {strconv.FormatBool(renewable)},
+	}
+	res := new(struct {
+		PositionID json.Number `json:"positionId"`
+		Success    bool        `json:"success"`
+	})
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/staking/purchase", params, true, res)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if !res.Success {
+		return "", errors.Errorf("failed to stake %s of %s", amount, productID)
+	}
+	return res.PositionID.String(), nil
+}
+
+// Unstake redeem staking position, positionID is required by locked products
+// and amount by flexible products
+func (account *Account) Unstake(product, productID, positionID, amount string) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"product":   {product},
+		"productId": {productID},
+	}
+	if positionID != "" {
+		params.Set("positionId", positionID)
+	}
+	if amount != "" {
+		params.Set("amount", amount)
+	}
+	res := new(struct {
+		Success bool `json:"success"`
+	})
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/staking/redeem", params, true, res)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !res.Success {
+		return errors.Errorf("failed to unstake %s", productID)
+	}
+	return nil
+}
+
+// checkStakingProduct return product type in upper case if valid
+func checkStakingProduct(product string) (string, error) {
+	product = strings.ToUpper(product)
+	if !StrContains(stakingProducts, product) {
+		return "", errors.Errorf("invalid staking product: %s", product)
+	}
+	return product, nil
+}
+
+func listStakingProducts(product, asset string) error {
+	product, err := checkStakingProduct(product)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return runOnce(func(account *Account) (interface{}, error) {
+		products, err := account.ListStakingProducts(product, asset)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return products, nil
+	})
+}
+
+func stake(product, productID, amount string, renewable bool) error {
+	product, err := checkStakingProduct(product)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if productID == "" || amount == "" {
+		return errors.New("product id and amount required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		positionID, err := account.Stake(product, productID, amount, renewable)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return map[string]string{"positionId": positionID}, nil
+	})
+}
+
+func unstake(product, productID, positionID, amount string) error {
+	product, err := checkStakingProduct(product)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if productID == "" {
+		return errors.New("product id required")
+	}
+	if positionID == "" && amount == "" {
+		return errors.New("position id of locked or amount of flexible product required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		err := account.Unstake(product, productID, positionID, amount)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+)
+
+// appendRecord append v as a JSON line to file in data dir
+func appendRecord(file string, v interface{}) error {
+	err := os.MkdirAll(dataDir, 0700)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dataDir, file), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return errors.Trace(err)
+}
+
+// readRecords call fn with each JSON line of file in data dir,
+// a missing file has no records
+func readRecords(file string, fn func(data []byte) error) error {
+	f, err := os.Open(filepath.Join(dataDir, file))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		err = fn(scanner.Bytes())
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(scanner.Err())
+}
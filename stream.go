@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/juju/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// combined stream endpoints
+const (
+	streamURL        = "wss://stream.binance.com:9443/stream"
+	futuresStreamURL = "wss://fstream.binance.com/stream"
+)
+
+// maxStreams is the max number of streams of a connection allowed by Binance
+const maxStreams = 1024
+
+// StreamHandler handle data of a stream event
+type StreamHandler func(stream string, data []byte)
+
+// StreamSubscription define streams subscribed by one consumer of a mux
+type StreamSubscription struct {
+	streams    []string
+	handler    StreamHandler
+	errHandler func(err error)
+	closed     chan struct{}
+}
+
+// StreamMux multiplex stream subscriptions over a single combined
+// websocket connection, subscriptions are managed with SUBSCRIBE and
+// UNSUBSCRIBE messages instead of opening one socket per stream. Several
+// consumers may subscribe the same stream, it is unsubscribed upstream
+// when the last of them unsubscribes.
+type StreamMux struct {
+	endpoint string
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	handlers map[string][]*StreamSubscription
+	nextID   int64
+	done     chan struct{}
+}
+
+var (
+	streamMuxesMu sync.Mutex
+	streamMuxes   = make(map[string]*StreamMux)
+)
+
+// getStreamMux return the shared stream mux of endpoint
+func getStreamMux(endpoint string) *StreamMux {
+	streamMuxesMu.Lock()
+	defer streamMuxesMu.Unlock()
+	mux, ok := streamMuxes[endpoint]
+	if !ok {
+		mux = &StreamMux{
+			endpoint: endpoint,
+			handlers: make(map[string][]*StreamSubscription),
+		}
+		streamMuxes[endpoint] = mux
+	}
+	return mux
+}
+
+// streamMessage define a message of combined stream or a subscription response
+type streamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+	ID     int64           `json:"id"`
+	Error  *struct {
+		Code int64  `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+// connect dial endpoint if not connected, must be called with lock held
+func (m *StreamMux) connect() error {
+	if m.conn != nil {
+		return nil
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(m.endpoint, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	m.conn = conn
+	m.done = make(chan struct{})
+	go m.readLoop(conn, m.done)
+	return nil
+}
+
+// notifyError pass err to error handlers of all subscriptions
+func (m *StreamMux) notifyError(err error) {
+	m.mu.Lock()
+	seen := make(map[*StreamSubscription]bool)
+	var subs []*StreamSubscription
+	for _, list := range m.handlers {
+		for _, sub := range list {
+			if !seen[sub] {
+				seen[sub] = true
+				subs = append(subs, sub)
+			}
+		}
+	}
+	m.mu.Unlock()
+	for _, sub := range subs {
+		if sub.errHandler != nil {
+			sub.errHandler(err)
+		}
+	}
+}
+
+func (m *StreamMux) readLoop(conn *websocket.Conn, done chan struct{}) {
+	defer close(done)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			m.mu.Lock()
+			closed := m.conn != conn
+			if !closed {
+				m.conn = nil
+			}
+			m.mu.Unlock()
+			if !closed {
+				m.notifyError(errors.Trace(err))
+			}
+			return
+		}
+		msg := new(streamMessage)
+		err = json.Unmarshal(data, msg)
+		if err != nil {
+			m.notifyError(errors.Trace(err))
+			continue
+		}
+		if msg.Error != nil {
+			m.notifyError(errors.Errorf("stream request %d failed: %d %s", msg.ID, msg.Error.Code, msg.Error.Msg))
+			continue
+		}
+		if msg.Stream == "" {
+			continue
+		}
+		m.mu.Lock()
+		subs := m.handlers[msg.Stream]
+		m.mu.Unlock()
+		for _, sub := range subs {
+			sub.handler(msg.Stream, msg.Data)
+		}
+	}
+}
+
+// send a subscription request, must be called with lock held
+func (m *StreamMux) send(method string, streams []string) error {
+	m.nextID++
+	err := m.conn.WriteJSON(map[string]interface{}{
+		"method": method,
+		"params": streams,
+		"id":     m.nextID,
+	})
+	return errors.Trace(err)
+}
+
+// Resubscribe reconnect and subscribe all streams with handlers again,
+// nothing is done if another consumer reconnected already
+func (m *StreamMux) Resubscribe() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn != nil {
+		return nil
+	}
+	err := m.connect()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var streams []string
+	for stream := range m.handlers {
+		streams = append(streams, stream)
+	}
+	if len(streams) == 0 {
+		return nil
+	}
+	return errors.Trace(m.send("SUBSCRIBE", streams))
+}
+
+// backoff range of reconnecting streams
+const (
+	streamBackoffMin = time.Second
+	streamBackoffMax = time.Minute
+)
+
+// streamMaxReconnects is the number of consecutive failed reconnects after
+// which websocket is considered blocked
+const streamMaxReconnects = 5
+
+// nextBackoff double backoff up to streamBackoffMax
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > streamBackoffMax {
+		backoff = streamBackoffMax
+	}
+	return backoff
+}
+
+// Serve keep the connection of sub up until it is unsubscribed, the
+// connection is reestablished with exponential backoff when it drops and
+// onReconnect is called before streams are subscribed again if not nil.
+// Events sent while disconnected are lost. An error is returned after
+// streamMaxReconnects consecutive failed reconnects.
+func (m *StreamMux) Serve(sub *StreamSubscription, onReconnect func()) error {
+	backoff := streamBackoffMin
+	connectedAt := time.Now()
+	failures := 0
+	for {
+		select {
+		case <-sub.closed:
+			return nil
+		case <-m.Done():
+		}
+		if time.Since(connectedAt) > streamBackoffMax {
+			backoff = streamBackoffMin
+		}
+		log.Printf("stream connection closed, reconnecting in %s", backoff)
+		time.Sleep(backoff)
+		backoff = nextBackoff(backoff)
+		if sub.Closed() {
+			return nil
+		}
+		if onReconnect != nil {
+			onReconnect()
+		}
+		err := m.Resubscribe()
+		if err != nil {
+			failures++
+			if failures >= streamMaxReconnects {
+				return errors.Annotatef(err, "failed to reconnect stream %d times", failures)
+			}
+			log.Print("failed to reconnect stream: ", err)
+			continue
+		}
+		failures = 0
+		connectedAt = time.Now()
+	}
+}
+
+// Subscribe subscribe streams like bnbbtc@ticker and handle their events
+// with handler, errors of the connection are passed to errHandler. Streams
+// already subscribed by other consumers are shared.
+func (m *StreamMux) Subscribe(streams []string, handler StreamHandler,
+	errHandler func(err error)) (*StreamSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.connect()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var unique, newStreams []string
+	for _, stream := range streams {
+		if StrContains(unique, stream) {
+			continue
+		}
+		unique = append(unique, stream)
+		if _, ok := m.handlers[stream]; !ok {
+			newStreams = append(newStreams, stream)
+		}
+	}
+	if len(m.handlers)+len(newStreams) > maxStreams {
+		return nil, errors.Errorf("too many streams, at most %d per connection", maxStreams)
+	}
+	sub := &StreamSubscription{
+		streams:    unique,
+		handler:    handler,
+		errHandler: errHandler,
+		closed:     make(chan struct{}),
+	}
+	for _, stream := range unique {
+		m.handlers[stream] = append(m.handlers[stream], sub)
+	}
+	if len(newStreams) == 0 {
+		return sub, nil
+	}
+	err = m.send("SUBSCRIBE", newStreams)
+	if err != nil {
+		m.remove(sub)
+		return nil, errors.Trace(err)
+	}
+	return sub, nil
+}
+
+// Unsubscribe remove the subscription, streams no other consumer
+// subscribes are unsubscribed upstream and the connection is closed when
+// no stream is left
+func (m *StreamMux) Unsubscribe(sub *StreamSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sub.Closed() {
+		return nil
+	}
+	dropped := m.remove(sub)
+	if m.conn == nil || len(dropped) == 0 {
+		return nil
+	}
+	if len(m.handlers) == 0 {
+		err := m.conn.Close()
+		m.conn = nil
+		return errors.Trace(err)
+	}
+	return errors.Trace(m.send("UNSUBSCRIBE", dropped))
+}
+
+// remove close sub and remove it from handlers, streams left without
+// handlers are returned. Must be called with lock held.
+func (m *StreamMux) remove(sub *StreamSubscription) []string {
+	close(sub.closed)
+	var dropped []string
+	for _, stream := range sub.streams {
+		subs := m.handlers[stream]
+		for i, s := range subs {
+			if s == sub {
+				// copy so readers of the old list are not affected
+				subs = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(subs) > 0 {
+			m.handlers[stream] = subs
+		} else if _, ok := m.handlers[stream]; ok {
+			delete(m.handlers, stream)
+			dropped = append(dropped, stream)
+		}
+	}
+	return dropped
+}
+
+// Closed return true if the subscription is unsubscribed
+func (sub *StreamSubscription) Closed() bool {
+	select {
+	case <-sub.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Streams return subscribed streams
+func (m *StreamMux) Streams() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var streams []string
+	for stream := range m.handlers {
+		streams = append(streams, stream)
+	}
+	return streams
+}
+
+// Done return a channel closed when the current connection is closed
+func (m *StreamMux) Done() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+	return m.done
+}
+
+// streamFormat is the output format of watch commands: json or ndjson
+var streamFormat = "json"
+
+// streamFormatFlag define the output format flag of watch commands
+var streamFormatFlag = cli.StringFlag{
+	Name:        "format",
+	Usage:       "output format: json, or ndjson for one event per line",
+	Value:       "json",
+	Destination: &streamFormat,
+}
+
+// checkStreamFormat validate output format of watch commands, ndjson
+// overrides the global output format
+func checkStreamFormat(c *cli.Context) error {
+	switch streamFormat {
+	case "json":
+	case "ndjson":
+		outputFormat = formatNDJSON
+	default:
+		return errors.Errorf("invalid format: %s", streamFormat)
+	}
+	return nil
+}
+
+var printEventMu sync.Mutex
+
+// printEvent print an event of a watch command, events printed from
+// several goroutines are not interleaved
+func printEvent(v interface{}) error {
+	printEventMu.Lock()
+	defer printEventMu.Unlock()
+	return print(v)
+}
+
+// watchStreams print raw events of streams subscribed over one combined connection
+func watchStreams(streams []string, futures bool) error {
+	if len(streams) == 0 {
+		return errors.New("streams required")
+	}
+	endpoint := streamURL
+	if futures {
+		endpoint = futuresStreamURL
+	}
+	if !forcePolling {
+		mux := getStreamMux(endpoint)
+		sub, err := mux.Subscribe(streams, func(stream string, data []byte) {
+			printEvent(map[string]json.RawMessage{stream: data})
+		}, func(e error) {
+			log.Print("stream error: ", e)
+		})
+		if err == nil {
+			err = mux.Serve(sub, nil)
+			mux.Unsubscribe(sub)
+			if err == nil {
+				return nil
+			}
+		}
+		if futures {
+			return errors.Trace(err)
+		}
+		log.Print("failed to connect websocket: ", err)
+	}
+	if futures {
+		return errors.New("polling of futures streams is not supported")
+	}
+	return pollStreams(streams, pollInterval, func(stream string, data interface{}) {
+		printEvent(map[string]interface{}{stream: data})
+	})
+}
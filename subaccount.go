@@ -0,0 +1,253 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// subAccountPageSize is the max number of sub-accounts per page
+const subAccountPageSize = 200
+
+// SubAccount define a sub-account of a master account
+type SubAccount struct {
+	Email                       string `json:"email"`
+	IsFreeze                    bool   `json:"isFreeze"`
+	CreateTime                  int64  `json:"createTime"`
+	IsManagedSubAccount         bool   `json:"isManagedSubAccount"`
+	IsAssetManagementSubAccount bool   `json:"isAssetManagementSubAccount"`
+}
+
+// ListSubAccounts list sub-accounts of the master account
+func (account *Account) ListSubAccounts() ([]*SubAccount, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var subAccounts []*SubAccount
+	for page := 1; ; page++ {
+		params := url.Values{
+			"page":  {strconv.Itoa(page)},
+			"limit": {strconv.Itoa(subAccountPageSize)},
+		}
+		res := new(struct {
+			SubAccounts []*SubAccount `json:"subAccounts"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/sub-account/list", params, true, res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		subAccounts = append(subAccounts, res.SubAccounts...)
+		if len(res.SubAccounts) < subAccountPageSize {
+			return subAccounts, nil
+		}
+	}
+}
+
+// GetSubAccountBalances get spot balances of the sub-account of email
+func (account *Account) GetSubAccountBalances(email string) ([]binance.Balance, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(struct {
+		Balances []binance.Balance `json:"balances"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v3/sub-account/assets",
+		url.Values{"email": {email}}, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res.Balances, nil
+}
+
+func listSubAccounts() error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		subAccounts, err := account.ListSubAccounts()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return subAccounts, nil
+	})
+}
+
+// SubAccountBalances define non-zero balances of sub-accounts by email with
+// balances summed over sub-accounts
+type SubAccountBalances struct {
+	SubAccounts map[string][]binance.Balance `json:"sub_accounts"`
+	Total       []binance.Balance            `json:"total"`
+}
+
+// listSubAccountBalances list non-zero spot balances of sub-accounts of
+// emails, all sub-accounts if empty
+func listSubAccountBalances(emails []string) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		subEmails := emails
+		if len(subEmails) == 0 {
+			subAccounts, err := account.ListSubAccounts()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			for _, sub := range subAccounts {
+				subEmails = append(subEmails, sub.Email)
+			}
+		}
+		res := &SubAccountBalances{SubAccounts: make(map[string][]binance.Balance)}
+		var all []binance.Balance
+		for _, email := range subEmails {
+			balances, err := account.GetSubAccountBalances(email)
+			if err != nil {
+				return nil, errors.Annotatef(err, "sub-account %s", email)
+			}
+			balances = nonZeroBalances(balances)
+			res.SubAccounts[email] = balances
+			all = append(all, balances...)
+		}
+		res.Total = sumBalances(all, nil)
+		return res, nil
+	})
+}
+
+// subAccountWalletTypes map wallet names to account types of sub-account transfer
+var subAccountWalletTypes = map[string]string{
+	"SPOT":         "SPOT",
+	"MARGIN":       "MARGIN",
+	"FUTURES":      "USDT_FUTURE",
+	"COIN-FUTURES": "COIN_FUTURE",
+}
+
+// subAccountWalletType return account type of wallet for sub-account transfer
+func subAccountWalletType(wallet string) (string, error) {
+	typ, ok := subAccountWalletTypes[strings.ToUpper(wallet)]
+	if !ok {
+		return "", errors.Errorf("invalid wallet: %s", wallet)
+	}
+	return typ, nil
+}
+
+// SubAccountTransfer define a transfer between master and sub-accounts,
+// empty email is the master account
+type SubAccountTransfer struct {
+	TranID          int64  `json:"tranId"`
+	FromEmail       string `json:"fromEmail"`
+	ToEmail         string `json:"toEmail"`
+	Asset           string `json:"asset"`
+	Amount          string `json:"amount"`
+	FromAccountType string `json:"fromAccountType"`
+	ToAccountType   string `json:"toAccountType"`
+	Status          string `json:"status"`
+	CreateTimeStamp int64  `json:"createTimeStamp"`
+}
+
+// TransferSubAccount transfer amount of asset from wallet of fromEmail to
+// wallet of toEmail, empty email is the master account
+func (account *Account) TransferSubAccount(fromEmail, toEmail, fromType, toType, asset, amount string) (int64, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"fromAccountType": {fromType},
+		"toAccountType":   {toType},
+		"asset":           {strings.ToUpper(asset)},
+		"amount":          {amount},
+	}
+	if fromEmail != "" {
+		params.Set("fromEmail", fromEmail)
+	}
+	if toEmail != "" {
+		params.Set("toEmail", toEmail)
+	}
+	res := new(struct {
+		TranID int64 `json:"tranId"`
+	})
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/sub-account/universalTransfer", params, true, res)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return res.TranID, nil
+}
+
+// ListSubAccountTransfers list transfers from fromEmail or to toEmail between
+// startTime and endTime, transfers from the master account if both are empty
+func (account *Account) ListSubAccountTransfers(fromEmail, toEmail string, startTime, endTime int64) ([]*SubAccountTransfer, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var transfers []*SubAccountTransfer
+	for page := 1; ; page++ {
+		params := url.Values{
+			"page":  {strconv.Itoa(page)},
+			"limit": {"500"},
+		}
+		if fromEmail != "" {
+			params.Set("fromEmail", fromEmail)
+		}
+		if toEmail != "" {
+			params.Set("toEmail", toEmail)
+		}
+		setTimeRange(params, 0, startTime, endTime)
+		res := new(struct {
+			Result     []*SubAccountTransfer `json:"result"`
+			TotalCount int                   `json:"totalCount"`
+		})
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/sub-account/universalTransfer", params, true, res)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		transfers = append(transfers, res.Result...)
+		if len(res.Result) == 0 || len(transfers) >= res.TotalCount {
+			return transfers, nil
+		}
+	}
+}
+
+// transferSubAccount transfer asset between master and sub-accounts or
+// between sub-accounts, empty email is the master account
+func transferSubAccount(fromEmail, toEmail, fromWallet, toWallet, asset, amount string) error {
+	if asset == "" || amount == "" {
+		return errors.New("asset and amount required")
+	}
+	if fromEmail == "" && toEmail == "" {
+		return errors.New("from-email or to-email required")
+	}
+	if fromEmail == toEmail && strings.EqualFold(fromWallet, toWallet) {
+		return errors.New("source and destination of transfer must be different")
+	}
+	fromType, err := subAccountWalletType(fromWallet)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	toType, err := subAccountWalletType(toWallet)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		tranID, err := account.TransferSubAccount(fromEmail, toEmail, fromType, toType, asset, amount)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return tranID, nil
+	})
+}
+
+// subAccountTransferSpan is the max time range of a request of sub-account transfers
+const subAccountTransferSpan = 30 * 24 * time.Hour
+
+// listSubAccountTransfers list sub-account transfers, latest first
+func listSubAccountTransfers(fromEmail, toEmail string, page *Page) error {
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var transfers []*SubAccountTransfer
+		err := page.Fetch(subAccountTransferSpan, func(startTime, endTime int64) error {
+			res, err := account.ListSubAccountTransfers(fromEmail, toEmail, startTime, endTime)
+			transfers = append(transfers, res...)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sort.SliceStable(transfers, func(i, j int) bool {
+			return transfers[i].CreateTimeStamp > transfers[j].CreateTimeStamp
+		})
+		return page.Apply(transfers), nil
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/juju/errors"
+)
+
+// formatTemplate is the output format of -o go-template='{{.Symbol}}'
+const formatTemplate = "go-template"
+
+// outputTemplate is the template of go-template output
+var outputTemplate *template.Template
+
+// templateFuncs define functions available in output templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), errors.Trace(err)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// parseOutputTemplate parse the template of a go-template=... output format
+func parseOutputTemplate(format string) error {
+	text := strings.TrimPrefix(strings.TrimPrefix(format, formatTemplate), "=")
+	if text == "" {
+		return errors.New("template required: go-template='{{.Symbol}} {{.Price}}'")
+	}
+	if redactOutput {
+		return errors.New("go-template output can not be redacted")
+	}
+	// transformed results are JSON objects, their keys don't match Go field
+	// names used in templates
+	if len(resultTransforms) > 0 {
+		return errors.New("go-template output can not be combined with --filter, --sort-by or --query")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	outputTemplate = tmpl
+	return nil
+}
+
+// templateItems return items a template is executed on: items of lists
+// and values of maps by sorted keys, lists in maps of results by account
+// are expanded too
+func templateItems(v interface{}, expandMaps bool) []interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return items
+	case reflect.Map:
+		if !expandMaps || rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		var items []interface{}
+		for _, key := range keys {
+			items = append(items, templateItems(rv.MapIndex(key).Interface(), false)...)
+		}
+		return items
+	}
+	return []interface{}{v}
+}
+
+// renderTemplate execute the output template on each item of a result,
+// fields are Go field names of the items: {{.Symbol}}
+func renderTemplate(v interface{}) (string, error) {
+	var b strings.Builder
+	for _, item := range templateItems(v, true) {
+		err := outputTemplate.Execute(&b, item)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
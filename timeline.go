@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// orderEventsFile is the file in data dir of fills and amendments of orders
+// recorded from user data streams by watch-account
+const orderEventsFile = "order_events.jsonl"
+
+// OrderEvent define an event in the lifecycle of an order
+type OrderEvent struct {
+	Time             int64  `json:"time"`
+	Event            string `json:"event"`
+	Price            string `json:"price,omitempty"`
+	Quantity         string `json:"quantity,omitempty"`
+	ExecutedQuantity string `json:"executedQuantity,omitempty"`
+	Commission       string `json:"commission,omitempty"`
+	CommissionAsset  string `json:"commissionAsset,omitempty"`
+	TradeID          int64  `json:"tradeId,omitempty"`
+}
+
+// recordOrderEvent save fills and amendments of an order update, failures are logged
+func recordOrderEvent(update *OrderUpdate) {
+	if update.ExecutionType != "TRADE" && update.ExecutionType != "REPLACED" {
+		return
+	}
+	err := appendRecord(orderEventsFile, update)
+	if err != nil {
+		log.Print("failed to record order event: ", err)
+	}
+}
+
+// loadOrderEvents return recorded updates of order orderID of symbol of account
+func loadOrderEvents(account, symbol string, orderID int64) ([]*OrderUpdate, error) {
+	var updates []*OrderUpdate
+	err := readRecords(orderEventsFile, func(data []byte) error {
+		update := new(OrderUpdate)
+		err := json.Unmarshal(data, update)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if update.Account == account && update.Symbol == symbol && update.OrderID == orderID {
+			updates = append(updates, update)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return updates, nil
+}
+
+// orderTimeline reconstruct chronological lifecycle events of order from
+// its trades, and fills and amendments recorded from user data streams
+func orderTimeline(order *binance.Order, trades []*binance.TradeV3, recorded []*OrderUpdate) []*OrderEvent {
+	events := []*OrderEvent{{
+		Time:     order.Time,
+		Event:    "CREATED",
+		Price:    order.Price,
+		Quantity: order.OrigQuantity,
+	}}
+	var fills []*OrderEvent
+	tradeIDs := make(map[int64]bool)
+	for _, trade := range trades {
+		tradeIDs[trade.ID] = true
+		fills = append(fills, &OrderEvent{
+			Time:            trade.Time,
+			Price:           trade.Price,
+			Quantity:        trade.Quantity,
+			Commission:      trade.Commission,
+			CommissionAsset: trade.CommissionAsset,
+			TradeID:         trade.ID,
+		})
+	}
+	for _, update := range recorded {
+		switch {
+		case update.ExecutionType == "REPLACED":
+			events = append(events, &OrderEvent{
+				Time:     update.Time,
+				Event:    "AMENDED",
+				Price:    update.Price,
+				Quantity: update.Quantity,
+			})
+		case update.ExecutionType == "TRADE" && !tradeIDs[update.TradeID]:
+			tradeIDs[update.TradeID] = true
+			fills = append(fills, &OrderEvent{
+				Time:            update.Time,
+				Price:           update.LastPrice,
+				Quantity:        update.LastQuantity,
+				Commission:      update.Commission,
+				CommissionAsset: update.CommissionAsset,
+				TradeID:         update.TradeID,
+			})
+		}
+	}
+	sort.Slice(fills, func(i, j int) bool {
+		return fills[i].Time < fills[j].Time || fills[i].Time == fills[j].Time && fills[i].TradeID < fills[j].TradeID
+	})
+	origQty := StrToFloat(order.OrigQuantity)
+	var executed float64
+	for i, fill := range fills {
+		executed += StrToFloat(fill.Quantity)
+		fill.Event = "PARTIALLY_FILLED"
+		// sums of quantities may be off by float rounding
+		last := i == len(fills)-1
+		if executed >= origQty*(1-1e-9) || (last && order.Status == binance.OrderStatusTypeFilled) {
+			fill.Event = "FILLED"
+		}
+		fill.ExecutedQuantity = fmt.Sprintf("%.8f", executed)
+		events = append(events, fill)
+	}
+	switch order.Status {
+	case binance.OrderStatusTypeCanceled, binance.OrderStatusTypeExpired,
+		binance.OrderStatusTypeRejected, binance.OrderStatusTypePendingCancel:
+		events = append(events, &OrderEvent{
+			Time:             order.UpdateTime,
+			Event:            string(order.Status),
+			ExecutedQuantity: order.ExecutedQuantity,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time < events[j].Time
+	})
+	return events
+}
+
+func showOrderTimeline(symbol string, orderID int64) error {
+	if symbol == "" || orderID == 0 {
+		return errors.New("symbol and order id required")
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		order, err := account.GetOrder(symbol, orderID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		trades, err := account.ListOrderTrades(symbol, orderID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		recorded, err := loadOrderEvents(account.Name, order.Symbol, orderID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return orderTimeline(order, trades, recorded), nil
+	})
+}
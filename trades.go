@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+const (
+	// historyPageSize is the max number of records of a request of
+	// myTrades and allOrders
+	historyPageSize = 1000
+	// orderHistorySpan is the max time range of a request of allOrders
+	orderHistorySpan = 24 * time.Hour
+)
+
+// pageLimit return the limit of a single request of page, 0 for the
+// default of the API
+func pageLimit(page *Page) int {
+	if page.Limit > 0 && page.Limit < historyPageSize {
+		return page.Limit
+	}
+	if page.Limit > 0 || page.All {
+		return historyPageSize
+	}
+	return 0
+}
+
+// morePages return true if more pages are fetched after got records of a
+// page of n records
+func morePages(page *Page, n, got int) bool {
+	if n < historyPageSize {
+		return false
+	}
+	if page.Limit > 0 {
+		return got < page.Limit
+	}
+	return page.All
+}
+
+// ListTradeHistory list trades of symbol in page. Trades are paged by id
+// from FromID, as the API can not combine it with a time range, or by
+// time windows if All is set.
+func (account *Account) ListTradeHistory(symbol string, page *Page) ([]*binance.TradeV3, error) {
+	if page.FromID == 0 && page.All {
+		var trades []*binance.TradeV3
+		err := page.Fetch(orderHistorySpan, func(startTime, endTime int64) error {
+			res, err := account.ListTrades(symbol, startTime, endTime)
+			trades = append(trades, res...)
+			return errors.Trace(err)
+		})
+		return trades, errors.Trace(err)
+	}
+	var trades []*binance.TradeV3
+	fromID := page.FromID
+	for {
+		s := account.NewListTradesService().Symbol(symbol)
+		if fromID > 0 {
+			s.FromID(fromID)
+		} else {
+			if page.StartTime > 0 {
+				s.StartTime(page.StartTime)
+			}
+			if page.EndTime > 0 {
+				s.EndTime(page.EndTime)
+			}
+		}
+		if limit := pageLimit(page); limit > 0 {
+			s.Limit(limit)
+		}
+		ctx, cancel := newContext()
+		res, err := s.Do(ctx)
+		cancel()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, trade := range res {
+			if page.EndTime > 0 && trade.Time > page.EndTime {
+				return trades, nil
+			}
+			if trade.Time >= page.StartTime {
+				trades = append(trades, trade)
+			}
+		}
+		if fromID == 0 || !morePages(page, len(res), len(trades)) {
+			return trades, nil
+		}
+		fromID = res[len(res)-1].ID + 1
+	}
+}
+
+// ListOrderHistory list orders of symbol in page, all statuses. Orders are
+// paged by id from FromID, or by time windows if All is set.
+func (account *Account) ListOrderHistory(symbol string, page *Page) ([]*binance.Order, error) {
+	var orders []*binance.Order
+	fetch := func(startTime, endTime int64) error {
+		fromID := page.FromID
+		for {
+			s := account.NewListOrdersService().Symbol(symbol)
+			if fromID > 0 {
+				s.OrderID(fromID)
+			} else {
+				if startTime > 0 {
+					s.StartTime(startTime)
+				}
+				if endTime > 0 {
+					s.EndTime(endTime)
+				}
+			}
+			if limit := pageLimit(page); limit > 0 {
+				s.Limit(limit)
+			}
+			ctx, cancel := newContext()
+			res, err := s.Do(ctx)
+			cancel()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, order := range res {
+				if endTime > 0 && order.Time > endTime {
+					return nil
+				}
+				if order.Time >= startTime {
+					orders = append(orders, order)
+				}
+			}
+			if !morePages(page, len(res), len(orders)) {
+				return nil
+			}
+			// pages of a time window continue by id too
+			fromID = res[len(res)-1].OrderID + 1
+		}
+	}
+	if page.FromID == 0 && page.All {
+		err := page.Fetch(orderHistorySpan, fetch)
+		return orders, errors.Trace(err)
+	}
+	return orders, errors.Trace(fetch(page.StartTime, page.EndTime))
+}
+
+// listTradeHistory list trades of symbol of accounts
+func listTradeHistory(symbol string, page *Page) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	// from id is sent to the API, only the limit is applied
+	limited := &Page{Limit: page.Limit}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		trades, err := account.ListTradeHistory(strings.ToUpper(symbol), page)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return limited.Apply(trades), nil
+	})
+}
+
+// listOrderHistory list orders of symbol of accounts
+func listOrderHistory(symbol string, page *Page) error {
+	if symbol == "" {
+		return errors.New("symbol required")
+	}
+	// from id is sent to the API, only the limit is applied
+	limited := &Page{Limit: page.Limit}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		orders, err := account.ListOrderHistory(strings.ToUpper(symbol), page)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return limited.Apply(orders), nil
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// walletTypes map wallet names to wallet types of universal transfer
+var walletTypes = map[string]string{
+	"SPOT":         "MAIN",
+	"FUNDING":      "FUNDING",
+	"MARGIN":       "MARGIN",
+	"FUTURES":      "UMFUTURE",
+	"COIN-FUTURES": "CMFUTURE",
+	"OPTION":       "OPTION",
+}
+
+// transferType return universal transfer type between wallets: MAIN_UMFUTURE
+func transferType(from, to string) (string, error) {
+	fromType, ok := walletTypes[strings.ToUpper(from)]
+	if !ok {
+		return "", errors.Errorf("invalid wallet: %s", from)
+	}
+	toType, ok := walletTypes[strings.ToUpper(to)]
+	if !ok {
+		return "", errors.Errorf("invalid wallet: %s", to)
+	}
+	if fromType == toType {
+		return "", errors.New("wallets of transfer must be different")
+	}
+	return fromType + "_" + toType, nil
+}
+
+// Transfer define a transfer between wallets
+type Transfer struct {
+	TranID    int64  `json:"tranId"`
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// UniversalTransfer transfer amount of asset with transfer type: MAIN_UMFUTURE
+func (account *Account) UniversalTransfer(transferType, asset, amount string) (int64, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"type":   {transferType},
+		"asset":  {strings.ToUpper(asset)},
+		"amount": {amount},
+	}
+	res := new(struct {
+		TranID int64 `json:"tranId"`
+	})
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/asset/transfer", params, true, res)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return res.TranID, nil
+}
+
+// ListUniversalTransfers list transfers of transfer type between startTime and endTime
+func (account *Account) ListUniversalTransfers(transferType string, startTime, endTime int64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	for current := 1; ; current++ {
+		params := url.Values{
+			"type":    {transferType},
+			"current": {strconv.Itoa(current)},
+			"size":    {"100"},
+		}
+		setTimeRange(params, 0, startTime, endTime)
+		res := new(struct {
+			Total int         `json:"total"`
+			Rows  []*Transfer `json:"rows"`
+		})
+		ctx, cancel := newContext()
+		err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/asset/transfer", params, true, res)
+		cancel()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		transfers = append(transfers, res.Rows...)
+		if len(res.Rows) == 0 || len(transfers) >= res.Total {
+			break
+		}
+	}
+	return transfers, nil
+}
+
+func transfer(from, to, asset, amount string) error {
+	if asset == "" || amount == "" {
+		return errors.New("asset and amount required")
+	}
+	typ, err := transferType(from, to)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		tranID, err := account.UniversalTransfer(typ, asset, amount)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return tranID, nil
+	})
+}
+
+// transferHistorySpan is the max time range of a request of transfer history
+const transferHistorySpan = 30 * 24 * time.Hour
+
+// listTransfers list transfers between wallets, in both directions if both
+// is set, latest first
+func listTransfers(from, to string, both bool, page *Page) error {
+	typ, err := transferType(from, to)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	types := []string{typ}
+	if both {
+		reverse, _ := transferType(to, from)
+		types = append(types, reverse)
+	}
+	return accountsDo(func(account *Account) (interface{}, error) {
+		var transfers []*Transfer
+		for _, typ := range types {
+			err := page.Fetch(transferHistorySpan, func(startTime, endTime int64) error {
+				res, err := account.ListUniversalTransfers(typ, startTime, endTime)
+				transfers = append(transfers, res...)
+				return errors.Trace(err)
+			})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		sort.SliceStable(transfers, func(i, j int) bool {
+			return transfers[i].Timestamp > transfers[j].Timestamp
+		})
+		return page.Apply(transfers), nil
+	})
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// OrderUpdate define an execution report of an order of an account
+type OrderUpdate struct {
+	Account         string `json:"account"`
+	Symbol          string `json:"symbol"`
+	OrderID         int64  `json:"order_id"`
+	ClientOrderID   string `json:"client_order_id"`
+	Side            string `json:"side"`
+	Type            string `json:"type"`
+	ExecutionType   string `json:"execution_type"`
+	Status          string `json:"status"`
+	Price           string `json:"price"`
+	Quantity        string `json:"quantity"`
+	LastPrice       string `json:"last_price"`
+	LastQuantity    string `json:"last_quantity"`
+	FilledQuantity  string `json:"filled_quantity"`
+	FilledQuote     string `json:"filled_quote"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commission_asset,omitempty"`
+	TradeID         int64  `json:"trade_id,omitempty"`
+	Time            int64  `json:"time"`
+}
+
+// BalanceChange define changed balances of an account, or the delta of an
+// asset for deposits, withdrawals and transfers
+type BalanceChange struct {
+	Account  string            `json:"account"`
+	Balances []binance.Balance `json:"balances,omitempty"`
+	Asset    string            `json:"asset,omitempty"`
+	Delta    string            `json:"delta,omitempty"`
+	Time     int64             `json:"time"`
+}
+
+// userEvent define an event of the user data stream
+type userEvent struct {
+	Event string `json:"e"`
+	Time  int64  `json:"E"`
+	// executionReport
+	Symbol          string `json:"s"`
+	ClientOrderID   string `json:"c"`
+	Side            string `json:"S"`
+	Type            string `json:"o"`
+	Quantity        string `json:"q"`
+	Price           string `json:"p"`
+	ExecutionType   string `json:"x"`
+	Status          string `json:"X"`
+	OrderID         int64  `json:"i"`
+	LastQuantity    string `json:"l"`
+	FilledQuantity  string `json:"z"`
+	LastPrice       string `json:"L"`
+	Commission      string `json:"n"`
+	CommissionAsset string `json:"N"`
+	TransactionTime int64  `json:"T"`
+	// keys differing only in case from keys above must be declared, or
+	// they are decoded into those fields
+	StopPrice         string `json:"P"`
+	OrigClientOrderID string `json:"C"`
+	TradeID           int64  `json:"t"`
+	Ignore            int64  `json:"I"`
+	CreationTime      int64  `json:"O"`
+	CumQuoteQuantity  string `json:"Z"`
+	QuoteQuantity     string `json:"Q"`
+	// outboundAccountPosition
+	Balances []struct {
+		Asset  string `json:"a"`
+		Free   string `json:"f"`
+		Locked string `json:"l"`
+	} `json:"B"`
+	// balanceUpdate
+	Asset string `json:"a"`
+	Delta string `json:"d"`
+}
+
+// parseUserEvent convert a message of the user data stream of account into
+// an OrderUpdate or BalanceChange, nil for other events
+func parseUserEvent(account string, message []byte) (interface{}, error) {
+	event := new(userEvent)
+	err := json.Unmarshal(message, event)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	switch event.Event {
+	case "executionReport":
+		// trade id is -1 for events other than fills
+		if event.ExecutionType != "TRADE" {
+			event.TradeID = 0
+		}
+		return &OrderUpdate{
+			Account:         account,
+			Symbol:          event.Symbol,
+			OrderID:         event.OrderID,
+			ClientOrderID:   event.ClientOrderID,
+			Side:            event.Side,
+			Type:            event.Type,
+			ExecutionType:   event.ExecutionType,
+			Status:          event.Status,
+			Price:           event.Price,
+			Quantity:        event.Quantity,
+			LastPrice:       event.LastPrice,
+			LastQuantity:    event.LastQuantity,
+			FilledQuantity:  event.FilledQuantity,
+			FilledQuote:     event.CumQuoteQuantity,
+			Commission:      event.Commission,
+			CommissionAsset: event.CommissionAsset,
+			TradeID:         event.TradeID,
+			Time:            event.TransactionTime,
+		}, nil
+	case "outboundAccountPosition":
+		change := &BalanceChange{Account: account, Time: event.Time}
+		for _, b := range event.Balances {
+			change.Balances = append(change.Balances, binance.Balance{Asset: b.Asset, Free: b.Free, Locked: b.Locked})
+		}
+		return change, nil
+	case "balanceUpdate":
+		return &BalanceChange{Account: account, Asset: event.Asset, Delta: event.Delta, Time: event.Time}, nil
+	}
+	return nil, nil
+}
+
+// StartUserStream start a user data stream and return its listen key
+func (account *Account) StartUserStream() (string, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	listenKey, err := account.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return listenKey, nil
+}
+
+// KeepaliveUserStream extend the validity of listenKey by 60 minutes
+func (account *Account) KeepaliveUserStream(listenKey string) error {
+	ctx, cancel := newContext()
+	defer cancel()
+	return errors.Trace(account.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx))
+}
+
+// userStreamKeepalive is the interval of listen key keepalive, keys expire
+// after 60 minutes without one
+const userStreamKeepalive = 30 * time.Minute
+
+// serveUserStream pass events of the user data stream of listenKey to
+// handle until the connection drops or the key can not be kept alive
+func serveUserStream(account *Account, listenKey string, handle func(event interface{})) error {
+	doneC, stopC, err := binance.WsUserDataServe(listenKey, func(message []byte) {
+		event, err := parseUserEvent(account.Name, message)
+		if err != nil {
+			log.Printf("failed to decode user event of %s: %s", account.Name, err)
+			return
+		}
+		if event != nil {
+			handle(event)
+		}
+	}, func(err error) {
+		log.Printf("user stream of %s failed: %s", account.Name, err)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ticker := time.NewTicker(userStreamKeepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-doneC:
+			return nil
+		case <-ticker.C:
+			err := account.KeepaliveUserStream(listenKey)
+			if err != nil {
+				log.Printf("failed to keep user stream of %s alive: %s", account.Name, err)
+				close(stopC)
+				<-doneC
+				return nil
+			}
+		}
+	}
+}
+
+// newOrderUpdate convert an order polled from REST into an order update
+func newOrderUpdate(account string, o *binance.Order, executionType string) *OrderUpdate {
+	return &OrderUpdate{
+		Account:        account,
+		Symbol:         o.Symbol,
+		OrderID:        o.OrderID,
+		ClientOrderID:  o.ClientOrderID,
+		Side:           string(o.Side),
+		Type:           string(o.Type),
+		ExecutionType:  executionType,
+		Status:         string(o.Status),
+		Price:          o.Price,
+		Quantity:       o.OrigQuantity,
+		FilledQuantity: o.ExecutedQuantity,
+		FilledQuote:    o.CummulativeQuoteQuantity,
+		Time:           o.UpdateTime,
+	}
+}
+
+// userPoller diff open orders and balances of an account between polls
+type userPoller struct {
+	account  *Account
+	orders   map[int64]*binance.Order
+	balances map[string]binance.Balance
+}
+
+// poll pass changes of open orders and balances since the previous poll to
+// handle. Orders leaving open orders are fetched for their final status.
+func (p *userPoller) poll(handle func(event interface{})) error {
+	orders, err := p.account.ListOpenOrders("")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = p.account.UpdateBalances(nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	first := p.orders == nil
+	current := make(map[int64]*binance.Order)
+	for _, o := range orders {
+		current[o.OrderID] = o
+		prev, ok := p.orders[o.OrderID]
+		switch {
+		case first:
+		case !ok:
+			handle(newOrderUpdate(p.account.Name, o, "NEW"))
+		case prev.ExecutedQuantity != o.ExecutedQuantity:
+			handle(newOrderUpdate(p.account.Name, o, "TRADE"))
+		}
+	}
+	for id, prev := range p.orders {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		o, err := p.account.GetOrder(prev.Symbol, id)
+		if err != nil {
+			log.Printf("failed to get order %d of %s: %s", id, p.account.Name, err)
+			continue
+		}
+		executionType := string(o.Status)
+		if o.Status == binance.OrderStatusTypeFilled {
+			executionType = "TRADE"
+		}
+		handle(newOrderUpdate(p.account.Name, o, executionType))
+	}
+	p.orders = current
+	balances := make(map[string]binance.Balance)
+	var changed []binance.Balance
+	for _, b := range p.account.Balances {
+		balances[b.Asset] = b
+		if prev, ok := p.balances[b.Asset]; !first && (!ok || prev != b) {
+			changed = append(changed, b)
+		}
+	}
+	p.balances = balances
+	if len(changed) > 0 {
+		handle(&BalanceChange{Account: p.account.Name, Balances: changed, Time: MilliTime(time.Now())})
+	}
+	return nil
+}
+
+// pollUserEvents poll open orders and balances of account every interval
+// and pass their changes to handle like user data stream events, used when
+// websocket is blocked. Orders opened and filled between polls are missed.
+func pollUserEvents(account *Account, interval time.Duration, handle func(event interface{})) {
+	log.Printf("WARNING: polling orders and balances of %s every %s, data may be less fresh than user data streams",
+		account.Name, interval)
+	p := &userPoller{account: account}
+	for {
+		err := p.poll(handle)
+		if err != nil {
+			log.Printf("failed to poll orders and balances of %s: %s", account.Name, err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// watchUserStream serve the user data stream of account from listenKey,
+// and restart it with a new listen key and exponential backoff whenever it
+// drops. Events sent while disconnected are lost. Orders and balances are
+// polled instead if polling is forced or reconnecting keeps failing.
+func watchUserStream(account *Account, listenKey string, handle func(event interface{})) {
+	if forcePolling {
+		pollUserEvents(account, pollInterval, handle)
+		return
+	}
+	backoff := streamBackoffMin
+	failures := 0
+	for {
+		connectedAt := time.Now()
+		err := serveUserStream(account, listenKey, handle)
+		if err != nil {
+			failures++
+			if failures >= streamMaxReconnects {
+				log.Printf("failed to connect user stream of %s %d times: %s", account.Name, failures, err)
+				pollUserEvents(account, pollInterval, handle)
+				return
+			}
+			log.Printf("failed to connect user stream of %s: %s", account.Name, err)
+		} else {
+			failures = 0
+		}
+		if time.Since(connectedAt) > streamBackoffMax {
+			backoff = streamBackoffMin
+		}
+		log.Printf("user stream of %s closed, reconnecting in %s", account.Name, backoff)
+		time.Sleep(backoff)
+		backoff = nextBackoff(backoff)
+		// the key may have expired while disconnected, starting a stream
+		// returns the active key if it has not
+		listenKey, err = account.StartUserStream()
+		for err != nil {
+			log.Printf("failed to start user stream of %s, retrying in %s: %s", account.Name, backoff, err)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
+			listenKey, err = account.StartUserStream()
+		}
+	}
+}
+
+// startUserStreams start user data streams of accounts and return their
+// listen keys by account key
+func startUserStreams(accounts map[string]*Account) (map[string]string, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("no account found")
+	}
+	listenKeys := make(map[string]string)
+	for key, account := range accounts {
+		listenKey, err := account.StartUserStream()
+		if err != nil {
+			return nil, errors.Annotatef(err, "account %s", account.Name)
+		}
+		listenKeys[key] = listenKey
+	}
+	return listenKeys, nil
+}
+
+// watchAccounts print order updates and balance changes of all accounts from
+// their user data streams in real time, filled orders are sent to fillHook
+// if set: an HTTP endpoint or a command, see notifyFill. Fills and
+// amendments are recorded for order-timeline.
+func watchAccounts(fillHook string) error {
+	accounts := findAccounts(name)
+	listenKeys, err := startUserStreams(accounts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	handle := func(event interface{}) {
+		printEvent(event)
+		update, ok := event.(*OrderUpdate)
+		if !ok {
+			return
+		}
+		recordOrderEvent(update)
+		if fillHook != "" && update.Status == "FILLED" {
+			go notifyFill(fillHook, newFillNotice(update))
+		}
+	}
+	var wg sync.WaitGroup
+	for key, account := range accounts {
+		wg.Add(1)
+		go func(account *Account, listenKey string) {
+			defer wg.Done()
+			watchUserStream(account, listenKey, handle)
+		}(account, listenKeys[key])
+	}
+	wg.Wait()
+	return nil
+}
@@ -1,5 +1,17 @@
 package main
 
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
 // StrContains check if string items contains s
 func StrContains(items []string, s string) bool {
 	for _, item := range items {
@@ -9,3 +21,73 @@ func StrContains(items []string, s string) bool {
 	}
 	return false
 }
+
+// SplitItems split comma separated items, e.g. ["BTCUSDT,ETHUSDT", "BNBBTC"]
+// into ["BTCUSDT", "ETHUSDT", "BNBBTC"]
+func SplitItems(items []string) []string {
+	var res []string
+	for _, item := range items {
+		for _, s := range strings.Split(item, ",") {
+			s = strings.TrimSpace(s)
+			if s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
+// StrToFloat convert s to float64, return 0 if s is not a number
+func StrToFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// relativeUnits are units of relative times: 7d for 7 days ago
+var relativeUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// ParseTime parse time in milliseconds, RFC3339, 2006-01-02 format or
+// relative to now like 7d, 12h, 30m into milliseconds since epoch
+func ParseTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	if unit, ok := relativeUnits[s[len(s)-1]]; ok {
+		if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n >= 0 {
+			return MilliTime(time.Now().Add(-time.Duration(n) * unit)), nil
+		}
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return MilliTime(t), nil
+		}
+	}
+	return 0, errors.Errorf("invalid time: %s", s)
+}
+
+// MilliTime convert t to milliseconds since epoch
+func MilliTime(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// confirm ask user to confirm prompt by typing yes
+func confirm(prompt string) (bool, error) {
+	defer pauseProgress()()
+	fmt.Fprintf(os.Stderr, "%s %s: ", prompt, T("confirm_answers"))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Trace(err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == "y" || answer == T("yes"), nil
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// DepositRecord define a crypto deposit
+type DepositRecord struct {
+	ID            string `json:"id"`
+	Amount        string `json:"amount"`
+	Coin          string `json:"coin"`
+	Network       string `json:"network"`
+	Status        int    `json:"status"`
+	Address       string `json:"address"`
+	AddressTag    string `json:"addressTag"`
+	TxID          string `json:"txId"`
+	InsertTime    int64  `json:"insertTime"`
+	TransferType  int    `json:"transferType"`
+	ConfirmTimes  string `json:"confirmTimes"`
+	UnlockConfirm int    `json:"unlockConfirm"`
+	WalletType    int    `json:"walletType"`
+}
+
+// ListDeposits list crypto deposits of asset, all assets if empty.
+// status: 0 pending, 6 credited but cannot withdraw, 1 success, -1 for all.
+func (account *Account) ListDeposits(asset string, status int, startTime, endTime int64) ([]*DepositRecord, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if asset != "" {
+		params.Set("coin", strings.ToUpper(asset))
+	}
+	if status >= 0 {
+		params.Set("status", strconv.Itoa(status))
+	}
+	setTimeRange(params, 0, startTime, endTime)
+	var deposits []*DepositRecord
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/capital/deposit/hisrec",
+		params, true, &deposits)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return deposits, nil
+}
+
+// DepositAddress define deposit address of an asset on a network
+type DepositAddress struct {
+	Coin    string `json:"coin"`
+	Address string `json:"address"`
+	Tag     string `json:"tag"`
+	URL     string `json:"url"`
+}
+
+// GetDepositAddress get deposit address of asset on network, default network if empty
+func (account *Account) GetDepositAddress(asset, network string) (*DepositAddress, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{"coin": {strings.ToUpper(asset)}}
+	if network != "" {
+		params.Set("network", strings.ToUpper(network))
+	}
+	address := new(DepositAddress)
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/capital/deposit/address",
+		params, true, address)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return address, nil
+}
+
+// CoinNetwork define deposit and withdraw settings of a coin on a network
+type CoinNetwork struct {
+	Network                 string `json:"network"`
+	Coin                    string `json:"coin"`
+	Name                    string `json:"name"`
+	IsDefault               bool   `json:"isDefault"`
+	DepositEnable           bool   `json:"depositEnable"`
+	WithdrawEnable          bool   `json:"withdrawEnable"`
+	WithdrawFee             string `json:"withdrawFee"`
+	WithdrawMin             string `json:"withdrawMin"`
+	WithdrawMax             string `json:"withdrawMax"`
+	MinConfirm              int    `json:"minConfirm"`
+	UnLockConfirm           int    `json:"unLockConfirm"`
+	SameAddress             bool   `json:"sameAddress"`
+	DepositDesc             string `json:"depositDesc,omitempty"`
+	WithdrawDesc            string `json:"withdrawDesc,omitempty"`
+	SpecialTips             string `json:"specialTips,omitempty"`
+	AddressRegex            string `json:"addressRegex,omitempty"`
+	MemoRegex               string `json:"memoRegex,omitempty"`
+	WithdrawIntegerMultiple string `json:"withdrawIntegerMultiple,omitempty"`
+}
+
+// CoinInfo define deposit and withdraw settings of a coin
+type CoinInfo struct {
+	Coin              string         `json:"coin"`
+	Name              string         `json:"name"`
+	DepositAllEnable  bool           `json:"depositAllEnable"`
+	WithdrawAllEnable bool           `json:"withdrawAllEnable"`
+	Free              string         `json:"free"`
+	Locked            string         `json:"locked"`
+	Freeze            string         `json:"freeze"`
+	Withdrawing       string         `json:"withdrawing"`
+	NetworkList       []*CoinNetwork `json:"networkList"`
+}
+
+// GetCoinInfo get deposit and withdraw settings of asset, all assets if empty
+func (account *Account) GetCoinInfo(asset string) ([]*CoinInfo, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var coins []*CoinInfo
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/capital/config/getall",
+		nil, true, &coins)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if asset == "" {
+		return coins, nil
+	}
+	for _, coin := range coins {
+		if strings.EqualFold(coin.Coin, asset) {
+			return []*CoinInfo{coin}, nil
+		}
+	}
+	return nil, errors.NotFoundf("asset %s", asset)
+}
+
+// Withdraw apply withdrawal of amount of asset to address on network, return withdraw id
+func (account *Account) Withdraw(asset, address, addressTag, amount, network string) (string, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{
+		"coin":    {strings.ToUpper(asset)},
+		"address": {address},
+		"amount":  {amount},
+	}
+	if addressTag != "" {
+		params.Set("addressTag", addressTag)
+	}
+	if network != "" {
+		params.Set("network", strings.ToUpper(network))
+	}
+	res := new(struct {
+		ID string `json:"id"`
+	})
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/capital/withdraw/apply",
+		params, true, res)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return res.ID, nil
+}
+
+// DustAsset define a small balance convertible to BNB
+type DustAsset struct {
+	Asset            string `json:"asset"`
+	AssetFullName    string `json:"assetFullName"`
+	AmountFree       string `json:"amountFree"`
+	ToBTC            string `json:"toBTC"`
+	ToBNB            string `json:"toBNB"`
+	ToBNBOffExchange string `json:"toBNBOffExchange"`
+	Exchange         string `json:"exchange"`
+}
+
+// DustAssets define small balances convertible to BNB
+type DustAssets struct {
+	Details            []*DustAsset `json:"details"`
+	TotalTransferBtc   string       `json:"totalTransferBtc"`
+	TotalTransferBNB   string       `json:"totalTransferBNB"`
+	DribbletPercentage string       `json:"dribbletPercentage"`
+}
+
+// ListDustAssets list small balances convertible to BNB
+func (account *Account) ListDustAssets() (*DustAssets, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(DustAssets)
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/asset/dust-btc", nil, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// DustTransferResult define result of converting small balances to BNB
+type DustTransferResult struct {
+	TotalServiceCharge string `json:"totalServiceCharge"`
+	TotalTransfered    string `json:"totalTransfered"`
+	TransferResult     []struct {
+		Amount              string `json:"amount"`
+		FromAsset           string `json:"fromAsset"`
+		OperateTime         int64  `json:"operateTime"`
+		ServiceChargeAmount string `json:"serviceChargeAmount"`
+		TranID              int64  `json:"tranId"`
+		TransferedAmount    string `json:"transferedAmount"`
+	} `json:"transferResult"`
+}
+
+// ConvertDust convert small balances of assets to BNB
+func (account *Account) ConvertDust(assets []string) (*DustTransferResult, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	for _, asset := range assets {
+		params.Add("asset", strings.ToUpper(asset))
+	}
+	res := new(DustTransferResult)
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/asset/dust", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// DustLog define a conversion of small balances to BNB
+type DustLog struct {
+	OperateTime              int64  `json:"operateTime"`
+	TotalTransferedAmount    string `json:"totalTransferedAmount"`
+	TotalServiceChargeAmount string `json:"totalServiceChargeAmount"`
+	TransID                  int64  `json:"transId"`
+	Details                  []struct {
+		TransID             int64  `json:"transId"`
+		ServiceChargeAmount string `json:"serviceChargeAmount"`
+		Amount              string `json:"amount"`
+		OperateTime         int64  `json:"operateTime"`
+		TransferedAmount    string `json:"transferedAmount"`
+		FromAsset           string `json:"fromAsset"`
+	} `json:"userAssetDribbletDetails"`
+}
+
+// ListDustLogs list conversions of small balances to BNB
+func (account *Account) ListDustLogs(startTime, endTime int64) ([]*DustLog, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	setTimeRange(params, 0, startTime, endTime)
+	res := new(struct {
+		Total    int        `json:"total"`
+		Dribblet []*DustLog `json:"userAssetDribblets"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/asset/dribblet", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res.Dribblet, nil
+}
+
+// AccountSnapshot define a daily snapshot of a wallet
+type AccountSnapshot struct {
+	Type       string          `json:"type"`
+	UpdateTime int64           `json:"updateTime"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// ListAccountSnapshots list daily snapshots of wallet type: SPOT, MARGIN or FUTURES
+func (account *Account) ListAccountSnapshots(walletType string, limit int, startTime, endTime int64) ([]*AccountSnapshot, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{"type": {strings.ToUpper(walletType)}}
+	setTimeRange(params, limit, startTime, endTime)
+	res := new(struct {
+		Code        int                `json:"code"`
+		Msg         string             `json:"msg"`
+		SnapshotVos []*AccountSnapshot `json:"snapshotVos"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/accountSnapshot", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res.SnapshotVos, nil
+}
+
+// TradeFee define maker and taker commission rates of a symbol
+type TradeFee struct {
+	Symbol          string `json:"symbol"`
+	MakerCommission string `json:"makerCommission"`
+	TakerCommission string `json:"takerCommission"`
+}
+
+// ListTradeFees list commission rates of symbol, or all symbols if empty
+func (account *Account) ListTradeFees(symbol string) ([]*TradeFee, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", strings.ToUpper(symbol))
+	}
+	var res []*TradeFee
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/asset/tradeFee", params, true, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// CommissionDiscount define discount of commission paid in BNB
+type CommissionDiscount struct {
+	EnabledForAccount bool   `json:"enabledForAccount"`
+	EnabledForSymbol  bool   `json:"enabledForSymbol"`
+	DiscountAsset     string `json:"discountAsset"`
+	Discount          string `json:"discount"`
+}
+
+// GetCommissionDiscount get discount of commission of symbol paid in BNB
+func (account *Account) GetCommissionDiscount(symbol string) (*CommissionDiscount, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{"symbol": {strings.ToUpper(symbol)}}
+	res := new(struct {
+		Discount *CommissionDiscount `json:"discount"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/api/v3/account/commission", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if res.Discount == nil {
+		return new(CommissionDiscount), nil
+	}
+	return res.Discount, nil
+}
+
+// APIRestrictions define permissions of an API key
+type APIRestrictions struct {
+	IPRestrict                     bool  `json:"ipRestrict"`
+	CreateTime                     int64 `json:"createTime"`
+	EnableReading                  bool  `json:"enableReading"`
+	EnableSpotAndMarginTrading     bool  `json:"enableSpotAndMarginTrading"`
+	EnableMargin                   bool  `json:"enableMargin"`
+	EnableFutures                  bool  `json:"enableFutures"`
+	EnableVanillaOptions           bool  `json:"enableVanillaOptions"`
+	EnablePortfolioMarginTrading   bool  `json:"enablePortfolioMarginTrading"`
+	EnableWithdrawals              bool  `json:"enableWithdrawals"`
+	EnableInternalTransfer         bool  `json:"enableInternalTransfer"`
+	PermitsUniversalTransfer       bool  `json:"permitsUniversalTransfer"`
+	TradingAuthorityExpirationTime int64 `json:"tradingAuthorityExpirationTime,omitempty"`
+}
+
+// GetAPIRestrictions get permissions of the API key of account
+func (account *Account) GetAPIRestrictions() (*APIRestrictions, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(APIRestrictions)
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/account/apiRestrictions", nil, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// TradingStatus define trading restrictions of an account
+type TradingStatus struct {
+	IsLocked           bool             `json:"isLocked"`
+	PlannedRecoverTime int64            `json:"plannedRecoverTime"`
+	TriggerCondition   map[string]int64 `json:"triggerCondition"`
+	UpdateTime         int64            `json:"updateTime"`
+}
+
+// AccountStatus define status of an account with its trading restrictions
+type AccountStatus struct {
+	Status        string         `json:"status"`
+	TradingStatus *TradingStatus `json:"tradingStatus"`
+	CanTrade      bool           `json:"canTrade"`
+	CanWithdraw   bool           `json:"canWithdraw"`
+	CanDeposit    bool           `json:"canDeposit"`
+	Restricted    bool           `json:"restricted"`
+}
+
+// GetAccountStatus get account status and trading status of account
+func (account *Account) GetAccountStatus() (*AccountStatus, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	status := new(struct {
+		Data string `json:"data"`
+	})
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/account/status", nil, true, status)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tradingStatus := new(struct {
+		Data *TradingStatus `json:"data"`
+	})
+	err = account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/account/apiTradingStatus", nil, true, tradingStatus)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info, err := account.NewGetAccountService().Do(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	res := &AccountStatus{
+		Status:        status.Data,
+		TradingStatus: tradingStatus.Data,
+		CanTrade:      info.CanTrade,
+		CanWithdraw:   info.CanWithdraw,
+		CanDeposit:    info.CanDeposit,
+	}
+	res.Restricted = res.Status != "Normal" || !res.CanTrade ||
+		(res.TradingStatus != nil && res.TradingStatus.IsLocked)
+	return res, nil
+}
+
+// FundingBalance define balance of an asset in the funding wallet
+type FundingBalance struct {
+	Asset        string `json:"asset"`
+	Free         string `json:"free"`
+	Locked       string `json:"locked"`
+	Freeze       string `json:"freeze"`
+	Withdrawing  string `json:"withdrawing"`
+	BtcValuation string `json:"btcValuation"`
+}
+
+// ListFundingBalances list non-zero balances of the funding wallet
+func (account *Account) ListFundingBalances() ([]*FundingBalance, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	var res []*FundingBalance
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/asset/get-funding-asset", nil, true, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// WalletBalances get balances of assets in wallet: spot, funding or all of
+// them summed by asset, all assets if assets is empty
+func (account *Account) WalletBalances(wallet string, assets []string) ([]binance.Balance, error) {
+	var balances []binance.Balance
+	switch strings.ToLower(wallet) {
+	case "spot", "":
+		err := account.UpdateBalances(assets)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return account.Balances, nil
+	case "all":
+		err := account.UpdateBalances(nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		balances = append(balances, account.Balances...)
+	case "funding":
+	default:
+		return nil, errors.Errorf("invalid wallet: %s", wallet)
+	}
+	funding, err := account.ListFundingBalances()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, f := range funding {
+		locked := StrToFloat(f.Locked) + StrToFloat(f.Freeze) + StrToFloat(f.Withdrawing)
+		balances = append(balances, binance.Balance{
+			Asset:  f.Asset,
+			Free:   f.Free,
+			Locked: strconv.FormatFloat(locked, 'f', -1, 64),
+		})
+	}
+	return sumBalances(balances, assets), nil
+}
+
+// sumBalances sum balances by asset in order of assets, or of first
+// appearance if assets is empty
+func sumBalances(balances []binance.Balance, assets []string) []binance.Balance {
+	var order []string
+	free := make(map[string]float64)
+	locked := make(map[string]float64)
+	for _, balance := range balances {
+		if _, ok := free[balance.Asset]; !ok {
+			order = append(order, balance.Asset)
+		}
+		free[balance.Asset] += StrToFloat(balance.Free)
+		locked[balance.Asset] += StrToFloat(balance.Locked)
+	}
+	if len(assets) > 0 {
+		order = assets
+	}
+	var res []binance.Balance
+	for _, asset := range order {
+		if _, ok := free[asset]; !ok {
+			continue
+		}
+		res = append(res, binance.Balance{
+			Asset:  asset,
+			Free:   strconv.FormatFloat(free[asset], 'f', 8, 64),
+			Locked: strconv.FormatFloat(locked[asset], 'f', 8, 64),
+		})
+	}
+	return res
+}
+
+// WithdrawRecord define a crypto withdrawal
+type WithdrawRecord struct {
+	ID              string `json:"id"`
+	Amount          string `json:"amount"`
+	TransactionFee  string `json:"transactionFee"`
+	Coin            string `json:"coin"`
+	Status          int    `json:"status"`
+	Address         string `json:"address"`
+	TxID            string `json:"txId"`
+	ApplyTime       string `json:"applyTime"`
+	Network         string `json:"network"`
+	WithdrawOrderID string `json:"withdrawOrderId,omitempty"`
+}
+
+// ListWithdrawals list crypto withdrawals between startTime and endTime
+func (account *Account) ListWithdrawals(startTime, endTime int64) ([]*WithdrawRecord, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	setTimeRange(params, 0, startTime, endTime)
+	var res []*WithdrawRecord
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/capital/withdraw/history", params, true, &res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// BNBBurn define whether BNB is used for spot trading fees and margin interest
+type BNBBurn struct {
+	SpotBNBBurn     bool `json:"spotBNBBurn"`
+	InterestBNBBurn bool `json:"interestBNBBurn"`
+}
+
+// GetBNBBurn get BNB burn settings of account
+func (account *Account) GetBNBBurn() (*BNBBurn, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	res := new(BNBBurn)
+	err := account.callAPI(ctx, http.MethodGet, apiURL, "/sapi/v1/bnbBurn", nil, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+// SetBNBBurn toggle BNB burn settings of account, settings are unchanged if nil
+func (account *Account) SetBNBBurn(spot, interest *bool) (*BNBBurn, error) {
+	ctx, cancel := newContext()
+	defer cancel()
+	params := url.Values{}
+	if spot != nil {
+		params.Set("spotBNBBurn", strconv.FormatBool(*spot))
+	}
+	if interest != nil {
+		params.Set("interestBNBBurn", strconv.FormatBool(*interest))
+	}
+	res := new(BNBBurn)
+	err := account.callAPI(ctx, http.MethodPost, apiURL, "/sapi/v1/bnbBurn", params, true, res)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
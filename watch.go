@@ -0,0 +1,443 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance"
+	"github.com/juju/errors"
+)
+
+// watchMarket handle events of market streams subscribed over one combined
+// connection, or poll their REST equivalents if websocket is blocked. The
+// connection is reestablished when it drops and onReconnect is called if
+// not nil, polling takes over when reconnecting keeps failing.
+func watchMarket(streams []string, onEvent StreamHandler, onPoll func(stream string, data interface{}),
+	onReconnect func()) error {
+	if !forcePolling {
+		mux := getStreamMux(streamURL)
+		sub, err := mux.Subscribe(streams, onEvent, func(e error) {
+			log.Print("stream error: ", e)
+		})
+		if err == nil {
+			err = mux.Serve(sub, onReconnect)
+			mux.Unsubscribe(sub)
+			if err == nil {
+				return nil
+			}
+		}
+		log.Print("failed to connect websocket: ", err)
+	}
+	return pollStreams(streams, pollInterval, onPoll)
+}
+
+// marketStreams return streams of kind for symbols: bnbbtc@ticker
+func marketStreams(symbols []string, kind string) []string {
+	streams := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		streams[i] = strings.ToLower(symbol) + "@" + kind
+	}
+	return streams
+}
+
+// streamSymbol return the upper case symbol of a market stream: BNBBTC
+func streamSymbol(stream string) string {
+	return strings.ToUpper(strings.SplitN(stream, "@", 2)[0])
+}
+
+// accountsSymbols return the union of default symbols of the selected
+// accounts, in order of account names
+func accountsSymbols() []string {
+	selected := findAccounts(name)
+	names := make([]string, 0, len(selected))
+	for k := range selected {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var symbols []string
+	for _, k := range names {
+		if selected[k] == nil {
+			continue
+		}
+		for _, symbol := range selected[k].Symbols {
+			symbol = strings.ToUpper(symbol)
+			if !StrContains(symbols, symbol) {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// upperSymbols return symbols in upper case, symbols of the selected
+// accounts if none is given, error if neither
+func upperSymbols(symbols []string) ([]string, error) {
+	if len(symbols) == 0 {
+		symbols = accountsSymbols()
+	}
+	if len(symbols) == 0 {
+		return nil, errors.New("symbols required")
+	}
+	res := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		res[i] = strings.ToUpper(symbol)
+	}
+	return res, nil
+}
+
+// PriceUpdate define a live price of a symbol
+type PriceUpdate struct {
+	Symbol        string `json:"symbol"`
+	Price         string `json:"price"`
+	ChangePercent string `json:"change_percent"`
+	High          string `json:"high"`
+	Low           string `json:"low"`
+	Time          int64  `json:"time"`
+}
+
+// priceFilter return true for updates moving the price by threshold percent
+// since the last passed update of the symbol, all updates if threshold is 0
+func priceFilter(threshold float64) func(update *PriceUpdate) bool {
+	last := make(map[string]float64)
+	return func(update *PriceUpdate) bool {
+		price := StrToFloat(update.Price)
+		prev, ok := last[update.Symbol]
+		if ok && prev > 0 && math.Abs(price-prev)/prev*100 < threshold {
+			return false
+		}
+		last[update.Symbol] = price
+		return true
+	}
+}
+
+// watchPrices print live prices of symbols from ticker streams, only when
+// price moves by threshold percent if set
+func watchPrices(symbols []string, threshold float64) error {
+	symbols, err := upperSymbols(symbols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pass := priceFilter(threshold)
+	return watchTickers(symbols, func(update *PriceUpdate) {
+		if pass(update) {
+			printEvent(update)
+		}
+	})
+}
+
+// watchTickers pass live prices of symbols from ticker streams to handle
+func watchTickers(symbols []string, handle func(update *PriceUpdate)) error {
+	return watchMarket(marketStreams(symbols, "ticker"), func(stream string, data []byte) {
+		event := new(struct {
+			Time          int64  `json:"E"`
+			Symbol        string `json:"s"`
+			ChangePercent string `json:"P"`
+			Price         string `json:"c"`
+			High          string `json:"h"`
+			Low           string `json:"l"`
+		})
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			log.Print("failed to decode ticker event: ", err)
+			return
+		}
+		handle(&PriceUpdate{
+			Symbol:        event.Symbol,
+			Price:         event.Price,
+			ChangePercent: event.ChangePercent,
+			High:          event.High,
+			Low:           event.Low,
+			Time:          event.Time,
+		})
+	}, func(stream string, data interface{}) {
+		stats, _ := data.([]*binance.PriceChangeStats)
+		for _, s := range stats {
+			handle(&PriceUpdate{
+				Symbol:        s.Symbol,
+				Price:         s.LastPrice,
+				ChangePercent: s.PriceChangePercent,
+				High:          s.HighPrice,
+				Low:           s.LowPrice,
+				Time:          s.CloseTime,
+			})
+		}
+	}, nil)
+}
+
+// TradeEvent define a public trade of a symbol, side is the taker side
+type TradeEvent struct {
+	Symbol   string `json:"symbol"`
+	ID       int64  `json:"id"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	Side     string `json:"side"`
+	Time     int64  `json:"time"`
+}
+
+// TradeSummary define volume and VWAP of trades of a symbol in a rolling window
+type TradeSummary struct {
+	Symbol      string  `json:"symbol"`
+	Window      string  `json:"window"`
+	Trades      int     `json:"trades"`
+	Volume      float64 `json:"volume"`
+	QuoteVolume float64 `json:"quote_volume"`
+	BuyVolume   float64 `json:"buy_volume"`
+	SellVolume  float64 `json:"sell_volume"`
+	VWAP        float64 `json:"vwap"`
+}
+
+// tradeWindow keep trades of the last window duration
+type tradeWindow struct {
+	mu     sync.Mutex
+	window time.Duration
+	trades []*TradeEvent
+	lastID int64
+}
+
+// Add add trade, trades seen before are ignored
+func (w *tradeWindow) Add(trade *TradeEvent) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if trade.ID <= w.lastID {
+		return false
+	}
+	w.lastID = trade.ID
+	w.trades = append(w.trades, trade)
+	return true
+}
+
+// Summary drop trades older than window and summarize the rest
+func (w *tradeWindow) Summary(symbol string) *TradeSummary {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	since := MilliTime(time.Now().Add(-w.window))
+	i := 0
+	for i < len(w.trades) && w.trades[i].Time < since {
+		i++
+	}
+	w.trades = w.trades[i:]
+	summary := &TradeSummary{Symbol: symbol, Window: w.window.String(), Trades: len(w.trades)}
+	for _, trade := range w.trades {
+		price, quantity := StrToFloat(trade.Price), StrToFloat(trade.Quantity)
+		summary.Volume += quantity
+		summary.QuoteVolume += price * quantity
+		if trade.Side == "BUY" {
+			summary.BuyVolume += quantity
+		} else {
+			summary.SellVolume += quantity
+		}
+	}
+	if summary.Volume > 0 {
+		summary.VWAP = summary.QuoteVolume / summary.Volume
+	}
+	return summary
+}
+
+// takerSide return taker side of a trade
+func takerSide(isBuyerMaker bool) string {
+	if isBuyerMaker {
+		return "SELL"
+	}
+	return "BUY"
+}
+
+// watchTrades print public trades of symbols, and every interval a summary
+// of trades of each symbol in the last window. Trades are not printed if
+// quiet is set.
+func watchTrades(symbols []string, interval, window time.Duration, quiet bool) error {
+	symbols, err := upperSymbols(symbols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if interval <= 0 {
+		return errors.Errorf("invalid interval: %s", interval)
+	}
+	if window <= 0 {
+		window = interval
+	}
+	windows := make(map[string]*tradeWindow)
+	for _, symbol := range symbols {
+		windows[symbol] = &tradeWindow{window: window}
+	}
+	handle := func(trade *TradeEvent) {
+		trades, ok := windows[trade.Symbol]
+		if ok && trades.Add(trade) && !quiet {
+			printEvent(trade)
+		}
+	}
+	go func() {
+		for range time.Tick(interval) {
+			for _, symbol := range symbols {
+				printEvent(windows[symbol].Summary(symbol))
+			}
+		}
+	}()
+	return watchMarket(marketStreams(symbols, "trade"), func(stream string, data []byte) {
+		event := new(struct {
+			Symbol       string `json:"s"`
+			ID           int64  `json:"t"`
+			Price        string `json:"p"`
+			Quantity     string `json:"q"`
+			Time         int64  `json:"T"`
+			IsBuyerMaker bool   `json:"m"`
+		})
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			log.Print("failed to decode trade event: ", err)
+			return
+		}
+		handle(&TradeEvent{
+			Symbol:   event.Symbol,
+			ID:       event.ID,
+			Price:    event.Price,
+			Quantity: event.Quantity,
+			Side:     takerSide(event.IsBuyerMaker),
+			Time:     event.Time,
+		})
+	}, func(stream string, data interface{}) {
+		recent, _ := data.([]*binance.Trade)
+		for _, t := range recent {
+			handle(&TradeEvent{
+				Symbol:   streamSymbol(stream),
+				ID:       t.ID,
+				Price:    t.Price,
+				Quantity: t.Quantity,
+				Side:     takerSide(t.IsBuyerMaker),
+				Time:     t.Time,
+			})
+		}
+	}, nil)
+}
+
+// klineSeries keep the latest closed klines of a symbol for indicators
+type klineSeries struct {
+	klines     []*binance.Kline
+	size       int
+	indicators []*Indicator
+}
+
+// Add append a closed kline and return it with indicator values
+func (s *klineSeries) Add(kline *binance.Kline) *KlineRow {
+	n := len(s.klines)
+	if n > 0 && s.klines[n-1].OpenTime >= kline.OpenTime {
+		s.klines[n-1] = kline
+	} else {
+		s.klines = append(s.klines, kline)
+	}
+	if len(s.klines) > s.size {
+		s.klines = s.klines[len(s.klines)-s.size:]
+	}
+	rows := klineRows(s.klines, s.indicators)
+	return rows[len(rows)-1]
+}
+
+// KlineEvent define a kline of a symbol with indicator values
+type KlineEvent struct {
+	Symbol string `json:"symbol"`
+	*KlineRow
+}
+
+// watchKlines print klines of symbols as they close, with indicator values
+// computed over the last size closed klines of each symbol. In-progress
+// updates are also printed if live is set.
+func watchKlines(symbols []string, interval string, size int, indicatorItems []string, live bool) error {
+	symbols, err := upperSymbols(symbols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	indicators, err := ParseIndicators(indicatorItems)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if size <= 0 {
+		return errors.Errorf("invalid history: %d", size)
+	}
+	account := publicAccount()
+	series := make(map[string]*klineSeries)
+	for _, symbol := range symbols {
+		s := &klineSeries{size: size, indicators: indicators}
+		if len(indicators) > 0 {
+			klines, err := account.ListKlines(symbol, interval, size+1, 0, 0)
+			if err != nil {
+				return errors.Annotatef(err, "symbol %s", symbol)
+			}
+			// the last kline is still open
+			for i := 0; i < len(klines)-1; i++ {
+				s.Add(klines[i])
+			}
+		}
+		series[symbol] = s
+	}
+	current := make(map[string]*binance.Kline)
+	return watchMarket(marketStreams(symbols, "kline_"+interval), func(stream string, data []byte) {
+		event := new(struct {
+			Symbol string `json:"s"`
+			Kline  struct {
+				OpenTime                 int64  `json:"t"`
+				CloseTime                int64  `json:"T"`
+				Open                     string `json:"o"`
+				Close                    string `json:"c"`
+				High                     string `json:"h"`
+				Low                      string `json:"l"`
+				Volume                   string `json:"v"`
+				TradeNum                 int64  `json:"n"`
+				Closed                   bool   `json:"x"`
+				QuoteAssetVolume         string `json:"q"`
+				TakerBuyBaseAssetVolume  string `json:"V"`
+				TakerBuyQuoteAssetVolume string `json:"Q"`
+			} `json:"k"`
+		})
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			log.Print("failed to decode kline event: ", err)
+			return
+		}
+		k := event.Kline
+		kline := &binance.Kline{
+			OpenTime:                 k.OpenTime,
+			Open:                     k.Open,
+			High:                     k.High,
+			Low:                      k.Low,
+			Close:                    k.Close,
+			Volume:                   k.Volume,
+			CloseTime:                k.CloseTime,
+			QuoteAssetVolume:         k.QuoteAssetVolume,
+			TradeNum:                 k.TradeNum,
+			TakerBuyBaseAssetVolume:  k.TakerBuyBaseAssetVolume,
+			TakerBuyQuoteAssetVolume: k.TakerBuyQuoteAssetVolume,
+		}
+		s, ok := series[event.Symbol]
+		if !ok {
+			return
+		}
+		if k.Closed {
+			printEvent(&KlineEvent{Symbol: event.Symbol, KlineRow: s.Add(kline)})
+		} else if live {
+			printEvent(&KlineEvent{Symbol: event.Symbol, KlineRow: &KlineRow{Kline: kline}})
+		}
+	}, func(stream string, data interface{}) {
+		klines, _ := data.([]*binance.Kline)
+		if len(klines) == 0 {
+			return
+		}
+		symbol := streamSymbol(stream)
+		kline, last := klines[len(klines)-1], current[symbol]
+		if last != nil && kline.OpenTime > last.OpenTime {
+			// fetch final values of the kline closed since last poll
+			closed, err := account.ListKlines(symbol, interval, 1, last.OpenTime, 0)
+			if err != nil {
+				log.Print("failed to get closed kline: ", err)
+			} else if len(closed) > 0 {
+				printEvent(&KlineEvent{Symbol: symbol, KlineRow: series[symbol].Add(closed[0])})
+			}
+		} else if live {
+			printEvent(&KlineEvent{Symbol: symbol, KlineRow: &KlineRow{Kline: kline}})
+		}
+		current[symbol] = kline
+	}, nil)
+}
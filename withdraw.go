@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// WithdrawPreview define a checked withdrawal before it is applied
+type WithdrawPreview struct {
+	Asset         string `json:"asset"`
+	Network       string `json:"network"`
+	Address       string `json:"address"`
+	AddressTag    string `json:"addressTag,omitempty"`
+	Amount        string `json:"amount"`
+	Fee           string `json:"fee"`
+	ReceiveAmount string `json:"receiveAmount"`
+	Free          string `json:"free"`
+	ID            string `json:"id,omitempty"`
+}
+
+// loadWhitelist load addresses from file, one address per line,
+// empty lines and lines starting with # are ignored
+func loadWhitelist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+	var addresses []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addresses = append(addresses, strings.Fields(line)[0])
+	}
+	return addresses, errors.Trace(scanner.Err())
+}
+
+// previewWithdraw check network, limits and balance of a withdrawal
+func (account *Account) previewWithdraw(asset, address, addressTag, amount, network string) (*WithdrawPreview, error) {
+	coins, err := account.GetCoinInfo(asset)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	coin := coins[0]
+	var selected *CoinNetwork
+	for _, n := range coin.NetworkList {
+		if (network == "" && n.IsDefault) || strings.EqualFold(n.Network, network) {
+			selected = n
+			break
+		}
+	}
+	if selected == nil {
+		return nil, errors.NotFoundf("network %s of %s", network, asset)
+	}
+	if !selected.WithdrawEnable {
+		return nil, errors.Errorf("withdrawal of %s on %s is disabled", asset, selected.Network)
+	}
+	value := StrToFloat(amount)
+	if value <= 0 {
+		return nil, errors.Errorf("invalid amount: %s", amount)
+	}
+	if value < StrToFloat(selected.WithdrawMin) {
+		return nil, errors.Errorf("amount %s is less than min withdrawal %s", amount, selected.WithdrawMin)
+	}
+	if max := StrToFloat(selected.WithdrawMax); max > 0 && value > max {
+		return nil, errors.Errorf("amount %s is more than max withdrawal %s", amount, selected.WithdrawMax)
+	}
+	if value > StrToFloat(coin.Free) {
+		return nil, errors.Errorf("amount %s is more than free balance %s", amount, coin.Free)
+	}
+	return &WithdrawPreview{
+		Asset:         coin.Coin,
+		Network:       selected.Network,
+		Address:       address,
+		AddressTag:    addressTag,
+		Amount:        amount,
+		Fee:           selected.WithdrawFee,
+		ReceiveAmount: fmt.Sprintf("%.8f", value-StrToFloat(selected.WithdrawFee)),
+		Free:          coin.Free,
+	}, nil
+}
+
+func withdraw(asset, address, addressTag, amount, network, whitelist string, dryRun, yes bool) error {
+	if asset == "" || address == "" || amount == "" {
+		return errors.New("asset, address and amount required")
+	}
+	if whitelist != "" {
+		addresses, err := loadWhitelist(whitelist)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !StrContains(addresses, address) {
+			return errors.Errorf("address %s is not in whitelist %s", address, whitelist)
+		}
+	}
+	// never withdraw the same amount from several accounts
+	selected := findAccounts(name)
+	if len(selected) != 1 {
+		return errors.Errorf("withdraw requires a single account, %d selected, set --name", len(selected))
+	}
+	var account *Account
+	for _, a := range selected {
+		account = a
+	}
+	if account == nil {
+		return errors.NotFoundf("account %s", name)
+	}
+	if !dryRun && !yes {
+		ok, err := confirm(T("confirm_withdraw", amount, strings.ToUpper(asset), network, address, account.Name))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !ok {
+			return errors.New(T("aborted"))
+		}
+	}
+	return accountsRun(selected, func(account *Account) (interface{}, error) {
+		preview, err := account.previewWithdraw(asset, address, addressTag, amount, network)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if dryRun {
+			return preview, nil
+		}
+		preview.ID, err = account.Withdraw(asset, address, addressTag, amount, preview.Network)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return preview, nil
+	})
+}